@@ -0,0 +1,92 @@
+package tz
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize is the number of locations LoadLocation keeps cached
+// by default.
+const DefaultCacheSize = 64
+
+// cache is a least recently used cache of loaded locations.
+var cache = struct {
+	sync.Mutex
+	size  int
+	ll    *list.List
+	names map[string]*list.Element
+}{
+	size:  DefaultCacheSize,
+	ll:    list.New(),
+	names: make(map[string]*list.Element),
+}
+
+type cacheEntry struct {
+	name string
+	loc  *time.Location
+}
+
+// SetCacheSize sets the number of locations LoadLocation keeps cached,
+// dropping the least recently used ones if there are more.
+// A size of 0 disables the cache.
+func SetCacheSize(size int) {
+	if size < 0 {
+		size = 0
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	cache.size = size
+	evict()
+}
+
+// Preload loads the locations with the given names into the cache,
+// so later calls to LoadLocation return them without parsing.
+// It returns the first error loading a location.
+func Preload(names ...string) error {
+	for _, name := range names {
+		if _, err := LoadLocation(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cached returns the cached location with the given name.
+func cached(name string) (*time.Location, bool) {
+	cache.Lock()
+	defer cache.Unlock()
+	e, ok := cache.names[name]
+	if !ok {
+		return nil, false
+	}
+	cache.ll.MoveToFront(e)
+	return e.Value.(*cacheEntry).loc, true
+}
+
+// addCached adds the location to the cache and returns it, or the location
+// already cached with the same name, so every caller gets the same one.
+func addCached(name string, loc *time.Location) *time.Location {
+	cache.Lock()
+	defer cache.Unlock()
+	if e, ok := cache.names[name]; ok {
+		cache.ll.MoveToFront(e)
+		return e.Value.(*cacheEntry).loc
+	}
+	if cache.size == 0 {
+		return loc
+	}
+	cache.names[name] = cache.ll.PushFront(&cacheEntry{name, loc})
+	evict()
+	return loc
+}
+
+// evict drops the least recently used locations over the cache size.
+// The cache must be locked.
+func evict() {
+	for cache.ll.Len() > cache.size {
+		e := cache.ll.Back()
+		cache.ll.Remove(e)
+		delete(cache.names, e.Value.(*cacheEntry).name)
+	}
+}
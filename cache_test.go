@@ -0,0 +1,82 @@
+package tz
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoadLocation_Cached(t *testing.T) {
+	defer SetCacheSize(DefaultCacheSize)
+	SetCacheSize(2)
+
+	a := mustLoadLocation(t, "Europe/Budapest")
+	if b := mustLoadLocation(t, "Europe/Budapest"); b != a {
+		t.Fatalf("got a different location for the same name")
+	}
+
+	// Europe/Budapest is the least recently used, and is dropped.
+	mustLoadLocation(t, "Europe/Vienna")
+	mustLoadLocation(t, "Europe/Prague")
+	if _, ok := cached("Europe/Budapest"); ok {
+		t.Fatalf("least recently used location is still cached")
+	}
+	if _, ok := cached("Europe/Vienna"); !ok {
+		t.Fatalf("location is not cached")
+	}
+
+	SetCacheSize(0)
+	if _, ok := cached("Europe/Vienna"); ok {
+		t.Fatalf("location is cached with the cache disabled")
+	}
+	mustLoadLocation(t, "Europe/Vienna")
+	if _, ok := cached("Europe/Vienna"); ok {
+		t.Fatalf("location is cached with the cache disabled")
+	}
+}
+
+func TestLoadLocation_Concurrent(t *testing.T) {
+	names := []string{"America/New_York", "Europe/London", "Asia/Tokyo"}
+	var wg sync.WaitGroup
+	locs := make([][]*time.Location, 8)
+	for i := range locs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for _, name := range names {
+				loc, err := LoadLocation(name)
+				if err != nil {
+					t.Errorf("error loading location: %s", err)
+					return
+				}
+				locs[i] = append(locs[i], loc)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := range locs {
+		for j := range names {
+			if locs[i][j] != locs[0][j] {
+				t.Fatalf("%s: got different locations", names[j])
+			}
+		}
+	}
+}
+
+func TestPreload(t *testing.T) {
+	defer SetCacheSize(DefaultCacheSize)
+	SetCacheSize(0)
+	SetCacheSize(DefaultCacheSize)
+
+	if err := Preload("Australia/Sydney", "Asia/Kolkata"); err != nil {
+		t.Fatalf("error preloading locations: %s", err)
+	}
+	for _, name := range []string{"Australia/Sydney", "Asia/Kolkata"} {
+		if _, ok := cached(name); !ok {
+			t.Fatalf("%s is not cached", name)
+		}
+	}
+	if err := Preload("Europe/Paris", "not-a-real-location"); err == nil {
+		t.Fatalf("got no error preloading an unknown location")
+	}
+}
@@ -0,0 +1,270 @@
+// Command tzgen generates the embedded data of package tz, zoneinfo.go,
+// from the source of a release of the IANA time zone database.
+//
+// The source is a tzdata tarball, as downloaded from
+// https://www.iana.org/time-zones, or a directory it was extracted to.
+// Every zone and link in it is compiled the way zic compiles it, to fat
+// data by default, as the embedded data has always been, or to slim data
+// with -b slim.
+//
+// Usage:
+//
+//	tzgen [-out zoneinfo.go] [-b fat|slim] [-files africa,antarctica,...] tzdata2019c.tar.gz
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	tz "github.com/nkovacs/go-tz"
+)
+
+// defaultFiles are the source files zic compiles by default.
+// The factory file is optional.
+const defaultFiles = "africa,antarctica,asia,australasia,europe,northamerica,southamerica,etcetera,backward,factory"
+
+func main() {
+	out := flag.String("out", "zoneinfo.go", "output file")
+	bloat := flag.String("b", "fat", "output fat or slim data")
+	files := flag.String("files", defaultFiles, "comma-separated source files to compile")
+	flag.Parse()
+	log.SetFlags(0)
+	log.SetPrefix("tzgen: ")
+	if flag.NArg() != 1 || *bloat != "fat" && *bloat != "slim" {
+		log.Fatal("usage: tzgen [-out zoneinfo.go] [-b fat|slim] [-files africa,...] tzdata.tar.gz|tzdata-dir")
+	}
+	opts := tz.CompileOptions{Fat: *bloat == "fat"}
+
+	names := strings.Split(*files, ",")
+	src, err := readSource(flag.Arg(0), append(names, "version"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var s tz.Source
+	backward := make(map[string]bool)
+	for _, name := range names {
+		data, ok := src[name]
+		if !ok {
+			if name == "factory" {
+				continue
+			}
+			log.Fatalf("%s: no source file %s", flag.Arg(0), name)
+		}
+		before := s.Links()
+		if err := s.Parse(name, bytes.NewReader(data)); err != nil {
+			log.Fatal(err)
+		}
+		if name == "backward" {
+			for link := range s.Links() {
+				if _, ok := before[link]; !ok {
+					backward[link] = true
+				}
+			}
+		}
+	}
+
+	// The version file is missing from tzdata.zi sources,
+	// which have a version comment instead.
+	version := strings.TrimSpace(string(src["version"]))
+	if version == "" {
+		version = s.Version()
+	}
+	if version == "" {
+		version = "unknown"
+	}
+
+	links := s.Links()
+	zones := s.Zones()
+	for link := range links {
+		zones = append(zones, link)
+	}
+	sort.Strings(zones)
+	compiled := make(map[string][]byte, len(zones))
+	for _, name := range zones {
+		l, err := s.CompileWithOptions(name, opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if compiled[name], err = l.MarshalBinary(); err != nil {
+			log.Fatalf("%s: %s", name, err)
+		}
+	}
+
+	code, err := generate(version, zones, compiled, links, backward)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, code, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readSource returns the contents of the named files of the source,
+// a tarball or a directory. Files that don't exist are left out.
+func readSource(source string, names []string) (map[string][]byte, error) {
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		want[name] = true
+	}
+	src := make(map[string][]byte)
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		for name := range want {
+			data, err := ioutil.ReadFile(filepath.Join(source, name))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			src[name] = data
+		}
+		return src, nil
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", source, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return src, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", source, err)
+		}
+		name := path.Base(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || !want[name] {
+			continue
+		}
+		if src[name], err = ioutil.ReadAll(tr); err != nil {
+			return nil, fmt.Errorf("%s: %s", source, err)
+		}
+	}
+}
+
+// generate returns the source of zoneinfo.go, with the compiled files
+// of the names, which are sorted, and the links.
+func generate(version string, names []string, files map[string][]byte, links map[string]string, backward map[string]bool) ([]byte, error) {
+	// Identical files, such as links, share one compressed payload.
+	var payloads []string
+	index := make(map[string]int)
+	indices := make([]int, len(names))
+	for i, name := range names {
+		data := string(files[name])
+		p, ok := index[data]
+		if !ok {
+			p = len(payloads)
+			index[data] = p
+			compressed, err := compress(files[name])
+			if err != nil {
+				return nil, err
+			}
+			payloads = append(payloads, compressed)
+		}
+		indices[i] = p
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by tzgen. DO NOT EDIT.\n\n")
+	buf.WriteString("package tz\n\n")
+	buf.WriteString("// zoneNames are the names of the embedded locations, sorted.\n")
+	buf.WriteString("var zoneNames = []string{\n")
+	for _, name := range names {
+		fmt.Fprintf(&buf, "%q,\n", name)
+	}
+	buf.WriteString("}\n\n")
+	buf.WriteString("// zonePayloads are the indices in zoneEnds of the data\n")
+	buf.WriteString("// of each location in zoneNames.\n")
+	buf.WriteString("var zonePayloads = []uint16{\n")
+	for i, name := range names {
+		fmt.Fprintf(&buf, "%d, // %s\n", indices[i], name)
+	}
+	buf.WriteString("}\n\n")
+	buf.WriteString("// zoneEnds are the offsets in zoneData where each payload ends.\n")
+	buf.WriteString("var zoneEnds = []uint32{\n")
+	end := 0
+	for _, payload := range payloads {
+		end += len(payload)
+		fmt.Fprintf(&buf, "%d,\n", end)
+	}
+	buf.WriteString("}\n\n")
+
+	linkNames := make([]string, 0, len(links))
+	for name := range links {
+		linkNames = append(linkNames, name)
+	}
+	sort.Strings(linkNames)
+	fmt.Fprintf(&buf, "// linkNames are the names of the links of the %s time zone database\n", version)
+	buf.WriteString("// the embedded data was compiled from, sorted.\n")
+	buf.WriteString("var linkNames = []string{\n")
+	for _, name := range linkNames {
+		fmt.Fprintf(&buf, "%q,\n", name)
+	}
+	buf.WriteString("}\n\n")
+	buf.WriteString("// linkTargets are the names of the zones the links in linkNames link to.\n")
+	buf.WriteString("var linkTargets = []string{\n")
+	for _, name := range linkNames {
+		fmt.Fprintf(&buf, "%q, // %s\n", links[name], name)
+	}
+	buf.WriteString("}\n\n")
+	buf.WriteString("// linkBackward reports whether each link in linkNames is from\n")
+	buf.WriteString("// the backward file.\n")
+	buf.WriteString("var linkBackward = []bool{\n")
+	for _, name := range linkNames {
+		fmt.Fprintf(&buf, "%t, // %s\n", backward[name], name)
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// zoneData is the payloads, compressed with DEFLATE, one after the other.\n")
+	buf.WriteString("const zoneData = \"\" +\n")
+	for i, payload := range payloads {
+		sep := " +"
+		if i == len(payloads)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(&buf, "%s%s\n", strconv.QuoteToASCII(payload), sep)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// compress compresses data with DEFLATE.
+func compress(data []byte) (string, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(data); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
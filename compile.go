@@ -0,0 +1,860 @@
+package tz
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CompileOptions are options for CompileWithOptions.
+type CompileOptions struct {
+	// Fat adds the transitions from 1900 through 2037 that the footer
+	// rule describes, and the standard/wall and UT/local indicators,
+	// as zic -b fat does, for readers that ignore the footer rule.
+	Fat bool
+}
+
+// Compile returns the location with the given name, compiled from the
+// source the way zic compiles it. A link is compiled as the zone it
+// links to, with the name of the link.
+//
+// Like zic by default, the location only has the transitions its footer
+// rule doesn't describe. Errors in the source are *SourceError values.
+func (s *Source) Compile(name string) (*Location, error) {
+	return s.CompileWithOptions(name, CompileOptions{})
+}
+
+// CompileWithOptions is like Compile, with the given options.
+func (s *Source) CompileWithOptions(name string, opts CompileOptions) (*Location, error) {
+	target := name
+	for i := 0; ; i++ {
+		next, ok := s.links[target]
+		if !ok {
+			break
+		}
+		if i == len(s.links) {
+			return nil, fmt.Errorf("link %s is part of a link cycle", name)
+		}
+		target = next
+	}
+	zones, ok := s.zones[target]
+	if !ok {
+		if target != name {
+			return nil, fmt.Errorf("link %s targets unknown zone %s", name, target)
+		}
+		return nil, errors.New("no zone or link named " + name)
+	}
+
+	lines := make([]zicLine, len(zones))
+	for i, z := range zones {
+		lines[i].sourceZone = z
+		if rules, ok := s.rules[z.rule]; ok && z.rule != "" {
+			lines[i].rules = rules
+			continue
+		}
+		var err error
+		if lines[i].save, lines[i].isDST, err = parseSave(z.rule); err != nil {
+			return nil, &SourceError{File: z.file, Line: z.line, Reason: "unknown rule " + z.rule}
+		}
+		if z.formatVerb == 's' {
+			return nil, &SourceError{File: z.file, Line: z.line, Reason: "%s in ruleless zone"}
+		}
+	}
+
+	c := zic{defaultType: -1, fat: opts.Fat}
+	if err := c.outzone(lines); err != nil {
+		return nil, err
+	}
+	return c.location(name)
+}
+
+// zicLine is a line of a zone, with its rules.
+type zicLine struct {
+	*sourceZone
+	rules []*sourceRule
+	// save and isDST are the saved time of a line without rules.
+	save  int64
+	isDST bool
+}
+
+// zicTrans is a transition, to the zone type with index typ.
+type zicTrans struct {
+	at        int64
+	typ       int
+	dontMerge bool
+}
+
+// zic holds the state of compiling a zone,
+// as in the outzone and writezone functions of zic.
+type zic struct {
+	types       []Zone
+	tx          []zicTrans
+	defaultType int
+	extend      string
+	version     int
+	fat         bool
+}
+
+// outzone computes the zone types and transitions of the lines of a zone,
+// and its footer rule.
+func (c *zic) outzone(lines []zicLine) error {
+	minYear, maxYear := int64(1970), int64(1970)
+	update := func(year int64) {
+		if year < minYear {
+			minYear = year
+		}
+		if year > maxYear {
+			maxYear = year
+		}
+	}
+	// A zone with a single line and rules that always have been
+	// and always will be in effect repeats every 400 years.
+	prodstic := len(lines) == 1
+	for i, l := range lines {
+		if i < len(lines)-1 {
+			update(l.until.loYear)
+		}
+		for _, r := range l.rules {
+			if r.loWasNum {
+				update(r.loYear)
+			}
+			if r.hiWasNum {
+				update(r.hiYear)
+			}
+			if r.loWasNum || r.hiWasNum {
+				prodstic = false
+			}
+		}
+	}
+
+	compat := c.stringZone(lines[len(lines)-1])
+	c.version = 2
+	if compat >= 2013 {
+		c.version = 3
+	}
+	// Without a footer rule, the transitions are listed for another
+	// 400 years, and a bit more, after which they repeat.
+	doExtend := compat < 0
+	if doExtend {
+		const years = 400 + 2
+		minYear = satAdd(minYear, -years)
+		maxYear = satAdd(maxYear, years)
+		if prodstic {
+			minYear = 1900
+			maxYear = minYear + years
+		}
+	}
+	if c.fat {
+		if minYear > 1900 {
+			minYear = 1900
+		}
+		if maxYear < 2037 {
+			maxYear = 2037
+		}
+	}
+
+	var (
+		startTime int64
+		// startIsStd and startIsUT are the indicators of the start time.
+		startIsStd, startIsUT bool
+		lastAtMax             = -1
+		todo                  []bool
+		temp                  []int64
+	)
+	for i := range lines {
+		l := &lines[i]
+		var (
+			prev  *sourceRule
+			added bool
+		)
+		// A guess that may well be corrected later.
+		save := int64(0)
+		useStart := i > 0 && lines[i-1].untilTime > alpha
+		useUntil := i < len(lines)-1
+		if useUntil && l.untilTime <= alpha {
+			continue
+		}
+		stdoff := l.stdoff
+		startAbbr := ""
+		startOff := l.stdoff
+
+		if len(l.rules) == 0 {
+			save = l.save
+			startAbbr = l.abbrev("", l.isDST, save)
+			typ, err := c.addType(l.sourceZone, stdoff+save, startAbbr, l.isDST, startIsStd, startIsUT)
+			if err != nil {
+				return err
+			}
+			if useStart {
+				c.addTrans(startTime, typ)
+				useStart = false
+			} else {
+				c.defaultType = typ
+			}
+		}
+		// The footer rule can only describe the years
+		// after the last rule that ends.
+		lastYear := int64(yearMin)
+		for _, r := range l.rules {
+			if r.hiYear != yearMax && r.hiYear > lastYear {
+				lastYear = r.hiYear
+			}
+		}
+		todo = append(todo[:0], make([]bool, len(l.rules))...)
+		temp = append(temp[:0], make([]int64, len(l.rules))...)
+		for year := minYear; len(l.rules) > 0 && year <= maxYear; year++ {
+			if useUntil && year > l.until.hiYear {
+				break
+			}
+			// Mark the rules in effect in the year,
+			// and the local times they take effect at.
+			for j, r := range l.rules {
+				todo[j] = year >= r.loYear && year <= r.hiYear
+				if todo[j] {
+					t, err := r.time(year)
+					if err != nil {
+						return &SourceError{File: r.file, Line: r.line, Reason: err.Error()}
+					}
+					temp[j] = t
+				}
+			}
+			for {
+				var untilTime int64
+				if useUntil {
+					// The until time in UT, with the current
+					// offset and saved time.
+					untilTime = l.untilTime
+					if !l.until.todIsUTC {
+						untilTime = timeAdd(untilTime, -stdoff)
+					}
+					if !l.until.todIsStd {
+						untilTime = timeAdd(untilTime, -save)
+					}
+				}
+
+				// Find the rule that takes effect earliest in the year.
+				k := -1
+				var kTime int64
+				for j, r := range l.rules {
+					if !todo[j] || temp[j] == alpha || temp[j] == omega {
+						continue
+					}
+					offset := stdoff
+					if r.todIsUTC {
+						offset = 0
+					}
+					if !r.todIsStd {
+						offset += save
+					}
+					t := timeAdd(temp[j], -offset)
+					if k < 0 || t < kTime {
+						k, kTime = j, t
+					} else if t == kTime {
+						return &SourceError{File: r.file, Line: r.line, Reason: "two rules for same instant"}
+					}
+				}
+				if k < 0 {
+					break
+				}
+				r := l.rules[k]
+				todo[k] = false
+				if useUntil && kTime >= untilTime {
+					if startAbbr == "" && stdoff+r.save == startOff {
+						startAbbr = l.abbrev(r.letters, r.isDST, r.save)
+					}
+					break
+				}
+				save = r.save
+				if useStart && kTime == startTime {
+					useStart = false
+				}
+				if useStart {
+					if kTime < startTime {
+						startOff = stdoff + save
+						startAbbr = l.abbrev(r.letters, r.isDST, r.save)
+						prev = r
+						continue
+					}
+					if startAbbr == "" && startOff == stdoff+save {
+						startAbbr = l.abbrev(r.letters, r.isDST, r.save)
+					}
+				}
+				// The footer rule describes the rest, once the line
+				// has a transition for it to take effect from.
+				if !c.fat && !useUntil && !doExtend && year > lastYear && (useStart || added) &&
+					prev != nil && r.hiYear == yearMax && prev.hiYear == yearMax {
+					break
+				}
+				typ, err := c.addType(l.sourceZone, stdoff+r.save, l.abbrev(r.letters, r.isDST, r.save), r.isDST, r.todIsStd, r.todIsUTC)
+				if err != nil {
+					return err
+				}
+				if c.defaultType < 0 && !r.isDST {
+					c.defaultType = typ
+				}
+				if r.hiYear == yearMax && !(lastAtMax >= 0 && kTime < c.tx[lastAtMax].at) {
+					lastAtMax = len(c.tx)
+				}
+				c.addTrans(kTime, typ)
+				prev = r
+				added = true
+			}
+		}
+		if useStart {
+			isDST := startOff != stdoff
+			if startAbbr == "" && l.formatVerb != 's' {
+				startAbbr = l.abbrev("", isDST, save)
+			}
+			if startAbbr == "" {
+				return &SourceError{File: l.file, Line: l.line, Reason: "can't determine time zone abbreviation to use just after until time"}
+			}
+			typ, err := c.addType(l.sourceZone, startOff, startAbbr, isDST, startIsStd, startIsUT)
+			if err != nil {
+				return err
+			}
+			if c.defaultType < 0 && !isDST {
+				c.defaultType = typ
+			}
+			c.addTrans(startTime, typ)
+		}
+		// The start time of the next line.
+		if useUntil {
+			startIsStd, startIsUT = l.until.todIsStd, l.until.todIsUTC
+			startTime = l.untilTime
+			if !l.until.todIsStd {
+				startTime = timeAdd(startTime, -save)
+			}
+			if !l.until.todIsUTC {
+				startTime = timeAdd(startTime, -stdoff)
+			}
+		}
+	}
+	if c.defaultType < 0 {
+		c.defaultType = 0
+	}
+	if lastAtMax >= 0 {
+		c.tx[lastAtMax].dontMerge = true
+	}
+	// A footer rule with daylight saving time is in effect from the
+	// last transition on, so it's kept even if it doesn't change the zone.
+	if last := c.lastTrans(); last >= 0 && strings.Contains(c.extend, ",") {
+		c.tx[last].dontMerge = true
+	}
+	if doExtend {
+		// Add a transition at the end of the listed years, if there
+		// isn't one near it, to show that there are no others.
+		jan1 := sourceRule{dayCode: dayOfMonth, day: 1}
+		last := c.lastTrans()
+		end, _ := jan1.time(maxYear - 1)
+		if last < 0 || c.tx[last].at < end {
+			typ := c.defaultType
+			if last >= 0 {
+				typ = c.tx[last].typ
+			}
+			at, _ := jan1.time(maxYear + 1)
+			c.addTrans(at, typ)
+			c.tx[len(c.tx)-1].dontMerge = true
+		}
+	}
+	c.optimize()
+	if c.fat && strings.Contains(c.extend, "<") {
+		// Like zic, work around a Qt bug with footer rules that have
+		// quoted abbreviations, by adding a transition that changes
+		// nothing just before 32-bit time runs out.
+		const y2038 = 1<<31 - 1
+		if n := len(c.tx); n > 0 && c.tx[n-1].at < y2038 {
+			c.addTrans(y2038, c.tx[n-1].typ)
+		}
+	}
+	return nil
+}
+
+// addType returns the index of the zone type,
+// adding it if there is no such type yet.
+// The indicators are only kept in fat data.
+func (c *zic) addType(z *sourceZone, offset int64, abbr string, isDST, isStd, isUT bool) (int, error) {
+	if offset < -1<<31 || offset > 1<<31-1 {
+		return 0, &SourceError{File: z.file, Line: z.line, Reason: "UT offset out of range"}
+	}
+	zone := Zone{Name: abbr, Offset: int(offset), IsDST: isDST}
+	if c.fat {
+		zone.Isstd, zone.Isutc = isStd, isUT
+	}
+	for i, t := range c.types {
+		if t == zone {
+			return i, nil
+		}
+	}
+	if len(c.types) == 256 {
+		return 0, &SourceError{File: z.file, Line: z.line, Reason: "too many local time types"}
+	}
+	c.types = append(c.types, zone)
+	return len(c.types) - 1, nil
+}
+
+func (c *zic) addTrans(at int64, typ int) {
+	c.tx = append(c.tx, zicTrans{at: at, typ: typ})
+}
+
+// lastTrans returns the index of the latest transition, or -1.
+func (c *zic) lastTrans() int {
+	last := -1
+	for i := range c.tx {
+		if last < 0 || c.tx[i].at > c.tx[last].at {
+			last = i
+		}
+	}
+	return last
+}
+
+// optimize sorts the transitions, and drops the ones that don't change
+// the zone, and the ones a later transition makes take no time.
+func (c *zic) optimize() {
+	sort.SliceStable(c.tx, func(i, j int) bool { return c.tx[i].at < c.tx[j].at })
+	tx := c.tx[:0]
+	for _, t := range c.tx {
+		if n := len(tx); n > 0 {
+			before := c.defaultType
+			if n > 1 {
+				before = tx[n-2].typ
+			}
+			if t.at+int64(c.types[tx[n-1].typ].Offset) <= tx[n-1].at+int64(c.types[before].Offset) {
+				tx[n-1].typ = t.typ
+				continue
+			}
+			if !t.dontMerge && sameZone(c.types[tx[n-1].typ], c.types[t.typ]) {
+				continue
+			}
+		}
+		tx = append(tx, t)
+	}
+	c.tx = tx
+}
+
+// location returns the compiled location, with the default zone type
+// in effect before the first transition.
+func (c *zic) location(name string) (*Location, error) {
+	if len(c.types) == 0 {
+		return nil, fmt.Errorf("%s: no local time types", name)
+	}
+	l := &Location{
+		Name:    name,
+		Version: c.version,
+		Extend:  c.extend,
+	}
+	if c.extend != "" {
+		r, err := ParseRule(c.extend)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid footer rule %q", name, c.extend)
+		}
+		l.Rule = r
+	}
+	c.omitUnused()
+	def := c.defaultType
+	l.Zone = c.types
+	for _, t := range c.tx {
+		l.Tx = append(l.Tx, ZoneTrans{When: t.at, Index: uint8(t.typ)})
+	}
+	if c.fat {
+		c.addCopies(l)
+		l.Zone = c.types
+	}
+
+	// Like zic, write the abbreviations in the order the types were
+	// created, and the default type first.
+	var abbrev []byte
+	for _, zone := range l.Zone {
+		abbrev, _ = addAbbrev(abbrev, zone.Name)
+	}
+	l.abbrev = string(abbrev)
+	l.Zone[0], l.Zone[def] = l.Zone[def], l.Zone[0]
+	for i := range l.Tx {
+		switch int(l.Tx[i].Index) {
+		case 0:
+			l.Tx[i].Index = uint8(def)
+		case def:
+			l.Tx[i].Index = 0
+		}
+		zone := l.Zone[l.Tx[i].Index]
+		l.Tx[i].Isstd, l.Tx[i].Isutc = zone.Isstd, zone.Isutc
+	}
+	l.keepFirstZone(l.Zone[0])
+	return l, nil
+}
+
+// omitUnused drops the types no transition uses, other than the default.
+func (c *zic) omitUnused() {
+	used := make([]bool, len(c.types))
+	used[c.defaultType] = true
+	for _, t := range c.tx {
+		used[t.typ] = true
+	}
+	index := make([]int, len(c.types))
+	types := c.types[:0]
+	for i, zone := range c.types {
+		if used[i] {
+			index[i] = len(types)
+			types = append(types, zone)
+		}
+	}
+	c.types = types
+	c.defaultType = index[c.defaultType]
+	for i := range c.tx {
+		c.tx[i].typ = index[c.tx[i].typ]
+	}
+}
+
+// addCopies adds the unused copies of zone types that zic adds to fat
+// data for some pre-2011 systems, first for the 32-bit data and then for
+// the 64-bit data, to the types. The types of l are still in creation order.
+func (c *zic) addCopies(l *Location) {
+	def := c.defaultType
+	for _, tx := range [][]ZoneTrans{l.transitions()} {
+		omit := make([]bool, len(c.types))
+		created := make([]int, len(c.types))
+		for i := range omit {
+			omit[i] = i != def
+			created[i] = i
+		}
+		for _, t := range tx {
+			omit[t.Index] = false
+		}
+		old0 := 0
+		for omit[old0] {
+			old0++
+		}
+		swap := func(i int) int {
+			switch i {
+			case old0:
+				return def
+			case def:
+				return old0
+			}
+			return i
+		}
+		c.types, _ = addLastUsedCopies(c.types, omit, tx, created, old0, swap)
+	}
+}
+
+// stringZone sets the footer rule from the last line of the zone, and
+// returns the tzcode release year of the features it needs, or -1 if
+// the zone can't be described by a footer rule.
+func (c *zic) stringZone(l zicLine) int {
+	var last [2]*sourceRule
+	for _, r := range l.rules {
+		i := 0
+		if r.isDST {
+			i = 1
+		}
+		cmp := ruleCmp(last[i], r)
+		if cmp == 0 {
+			return -1
+		}
+		if cmp < 0 {
+			last[i] = r
+		}
+	}
+	std, dst := last[0], last[1]
+	var dstCmp int
+	switch {
+	case len(l.rules) > 0:
+		dstCmp = ruleCmp(dst, std)
+	case l.isDST:
+		dstCmp = 1
+	default:
+		dstCmp = -1
+	}
+	stdLine, dstLine := l, l
+
+	if dstCmp < 0 {
+		// Standard time all year.
+		dst = nil
+	} else if dstCmp > 0 {
+		// Daylight saving time all year, described as
+		// negative daylight saving time from January 1
+		// to the end of the year.
+		save := l.save
+		if dst != nil {
+			save = dst.save
+		}
+		if save >= 0 {
+			stdLine.sourceZone = &sourceZone{stdoff: l.stdoff + 2*save, format: "XXX"}
+			dstLine.sourceZone = &sourceZone{stdoff: l.stdoff + 2*save, format: l.format, formatVerb: l.formatVerb}
+		}
+		dstRule := &sourceRule{dayCode: dayOfMonth, day: 1, isDST: true, save: -save}
+		if save < 0 {
+			dstRule.save = save
+		}
+		if dst != nil {
+			dstRule.letters = dst.letters
+		}
+		stdRule := &sourceRule{month: 11, dayCode: dayOfMonth, day: 31, tod: secondsPerDay + dstRule.save}
+		if save < 0 && std != nil {
+			stdRule.letters = std.letters
+		}
+		std, dst = stdRule, dstRule
+	}
+
+	var b strings.Builder
+	letters := ""
+	if std != nil {
+		letters = std.letters
+	}
+	b.WriteString(quoteAbbrev(stdLine.abbrev(letters, false, 0)))
+	off, ok := stringOffset(-stdLine.stdoff)
+	if !ok {
+		return -1
+	}
+	b.WriteString(off)
+	if dst == nil {
+		c.extend = b.String()
+		return 0
+	}
+	b.WriteString(quoteAbbrev(dstLine.abbrev(dst.letters, dst.isDST, dst.save)))
+	if dst.save != secondsPerHour {
+		off, ok := stringOffset(-(dstLine.stdoff + dst.save))
+		if !ok {
+			return -1
+		}
+		b.WriteString(off)
+	}
+	compat := 0
+	for _, r := range []*sourceRule{dst, std} {
+		s, rc := stringRule(r, dst.save, stdLine.stdoff)
+		if rc < 0 {
+			return -1
+		}
+		if rc > compat {
+			compat = rc
+		}
+		b.WriteByte(',')
+		b.WriteString(s)
+	}
+	c.extend = b.String()
+	return compat
+}
+
+// ruleCmp orders rules by the year they end, and rules that end in
+// the same year other than "maximum" by when they take effect.
+func ruleCmp(a, b *sourceRule) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	case a.hiYear != b.hiYear:
+		if a.hiYear < b.hiYear {
+			return -1
+		}
+		return 1
+	case a.hiYear == yearMax:
+		return 0
+	case a.month != b.month:
+		return a.month - b.month
+	}
+	return a.day - b.day
+}
+
+// stringRule returns the POSIX TZ date and time the rule takes effect at,
+// and the tzcode release year of the features it needs, or -1 if it
+// can't be described.
+func stringRule(r *sourceRule, save, stdoff int64) (string, int) {
+	tod := r.tod
+	compat := 0
+	var s string
+	if r.dayCode == dayOfMonth {
+		if r.month == 1 && r.day == 29 {
+			return "", -1
+		}
+		total := daysBefore[r.month]
+		// Omit the "J" in January and February, as that's shorter.
+		if r.month <= 1 {
+			s = strconv.Itoa(total + r.day - 1)
+		} else {
+			s = "J" + strconv.Itoa(total+r.day)
+		}
+	} else {
+		weekday := r.weekday
+		var week int
+		if r.dayCode == weekdayOnOrAft || r.day != daysIn(r.month+1, 2000) {
+			// Shift to the weekday on or before the day, if needed,
+			// which is one of the first four weeks.
+			off := (r.day - 1) % 7
+			week = 1 + (r.day-1)/7
+			if r.dayCode == weekdayOnOrBef {
+				off = r.day % 7
+				week = r.day / 7
+			}
+			if off != 0 {
+				compat = 2013
+			}
+			weekday -= off
+			tod += int64(off) * secondsPerDay
+		} else {
+			week = 5
+		}
+		if weekday < 0 {
+			weekday += 7
+		}
+		s = fmt.Sprintf("M%d.%d.%d", r.month+1, week, weekday)
+	}
+	if r.todIsUTC {
+		tod += stdoff
+	}
+	if r.todIsStd && !r.isDST {
+		tod += save
+	}
+	if tod != 2*secondsPerHour {
+		off, ok := stringOffset(tod)
+		if !ok {
+			return "", -1
+		}
+		s += "/" + off
+		if tod < 0 && compat < 2013 {
+			compat = 2013
+		} else if tod >= secondsPerDay && compat < 1994 {
+			compat = 1994
+		}
+	}
+	return s, compat
+}
+
+// stringOffset formats an offset or time of day for a POSIX TZ rule.
+// It reports false if the offset is a week or more.
+func stringOffset(off int64) (string, bool) {
+	if off <= -7*secondsPerDay || off >= 7*secondsPerDay {
+		return "", false
+	}
+	return formatRuleOffset(int(off)), true
+}
+
+// quoteAbbrev quotes an abbreviation for a POSIX TZ rule,
+// unless it's only letters.
+func quoteAbbrev(abbr string) string {
+	if abbr != "" && strings.Trim(abbr, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz") == "" {
+		return abbr
+	}
+	return "<" + abbr + ">"
+}
+
+// abbrev returns the abbreviation the line's format gives
+// for the letters of a rule, or of the line, with the given
+// saved time.
+func (z *sourceZone) abbrev(letters string, isDST bool, save int64) string {
+	if i := strings.IndexByte(z.format, '/'); i >= 0 {
+		if isDST {
+			return z.format[i+1:]
+		}
+		return z.format[:i]
+	}
+	switch z.formatVerb {
+	case 's':
+		return strings.Replace(z.format, "%s", letters, 1)
+	case 'z':
+		return strings.Replace(z.format, "%z", offsetAbbrev(z.stdoff+save), 1)
+	}
+	return z.format
+}
+
+// offsetAbbrev returns the abbreviation for %z, such as +0530 or -03.
+func offsetAbbrev(off int64) string {
+	sign := byte('+')
+	if off < 0 {
+		sign = '-'
+		off = -off
+	}
+	h, m, s := off/secondsPerHour, off/secondsPerMinute%60, off%secondsPerMinute
+	abbr := fmt.Sprintf("%c%02d", sign, h)
+	if m != 0 || s != 0 {
+		abbr += fmt.Sprintf("%02d", m)
+		if s != 0 {
+			abbr += fmt.Sprintf("%02d", s)
+		}
+	}
+	return abbr
+}
+
+// time returns the local time the rule takes effect at in the given year,
+// in seconds since 1970 as if the local time were UT, or alpha or omega
+// for the years minimum and maximum.
+func (r *sourceRule) time(year int64) (int64, error) {
+	switch year {
+	case yearMin:
+		return alpha, nil
+	case yearMax:
+		return omega, nil
+	}
+	// Years far outside the range of the data are the start or
+	// end of time.
+	if year < -1<<32 {
+		return alpha, nil
+	}
+	if year > 1<<32 {
+		return omega, nil
+	}
+	day := r.day
+	leap := year%4 == 0 && (year%100 != 0 || year%400 == 0)
+	if r.month == 1 && day == 29 && !leap {
+		if r.dayCode != weekdayOnOrBef {
+			return 0, errors.New("use of 2/29 in non leap-year")
+		}
+		day = 28
+	}
+	days := daysSinceEpoch(year, r.month) + int64(day-1)
+	if r.dayCode != dayOfMonth {
+		// January 1, 1970 was a Thursday.
+		weekday := int((days%7 + 7 + 4) % 7)
+		for weekday != r.weekday {
+			if r.dayCode == weekdayOnOrAft {
+				days++
+				weekday = (weekday + 1) % 7
+			} else {
+				days--
+				weekday = (weekday + 6) % 7
+			}
+		}
+	}
+	return days*secondsPerDay + r.tod, nil
+}
+
+// daysSinceEpoch returns the number of days from January 1, 1970
+// to the first day of the month, 0 to 11, of the year.
+func daysSinceEpoch(year int64, month int) int64 {
+	// Count years from March, so the leap day is at the end.
+	y := year
+	if month < 2 {
+		y--
+	}
+	era := y / 400
+	if y < 0 && y%400 != 0 {
+		era--
+	}
+	yoe := y - era*400
+	mp := int64((month + 10) % 12)
+	doy := (153*mp + 2) / 5
+	doe := yoe*365 + yoe/4 - yoe/100 + doy
+	return era*146097 + doe - 719468
+}
+
+// timeAdd adds d seconds to t, leaving the start and end of time as they are.
+func timeAdd(t, d int64) int64 {
+	if t == alpha || t == omega {
+		return t
+	}
+	return t + d
+}
+
+// satAdd adds d to the year, saturating at the years minimum and maximum.
+func satAdd(year, d int64) int64 {
+	switch {
+	case d < 0 && year < yearMin-d:
+		return yearMin
+	case d > 0 && year > yearMax-d:
+		return yearMax
+	}
+	return year + d
+}
@@ -0,0 +1,180 @@
+package tz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// testSource is source for some of the embedded locations, as in the
+// 2019c time zone database, partly in the compact form of tzdata.zi.
+const testSource = `# Rule	NAME	FROM	TO	-	IN	ON	AT	SAVE	LETTER/S
+Rule	US	1918	1919	-	Mar	lastSun	2:00	1:00	D
+Rule	US	1918	1919	-	Oct	lastSun	2:00	0	S
+Rule	US	1942	only	-	Feb	9	2:00	1:00	W # War
+Rule	US	1945	only	-	Aug	14	23:00u	1:00	P # Peace
+Rule	US	1945	only	-	Sep	30	2:00	0	S
+Rule	US	1967	2006	-	Oct	lastSun	2:00	0	S
+Rule	US	1967	1973	-	Apr	lastSun	2:00	1:00	D
+Rule	US	1974	only	-	Jan	6	2:00	1:00	D
+Rule	US	1975	only	-	Feb	23	2:00	1:00	D
+Rule	US	1976	1986	-	Apr	lastSun	2:00	1:00	D
+Rule	US	1987	2006	-	Apr	Sun>=1	2:00	1:00	D
+Rule	US	2007	max	-	Mar	Sun>=8	2:00	1:00	D
+Rule	US	2007	max	-	Nov	Sun>=1	2:00	0	S
+Rule	NYC	1920	only	-	Mar	lastSun	2:00	1:00	D
+Rule	NYC	1920	only	-	Oct	lastSun	2:00	0	S
+Rule	NYC	1921	1966	-	Apr	lastSun	2:00	1:00	D
+Rule	NYC	1921	1954	-	Sep	lastSun	2:00	0	S
+Rule	NYC	1955	1966	-	Oct	lastSun	2:00	0	S
+# Zone	NAME		STDOFF	RULES	FORMAT	[UNTIL]
+Zone America/New_York	-4:56:02 -	LMT	1883 Nov 18 12:03:58
+			-5:00	US	E%sT	1920
+			-5:00	NYC	E%sT	1942
+			-5:00	US	E%sT	1946
+			-5:00	NYC	E%sT	1967
+			-5:00	US	E%sT
+Link America/New_York US/Eastern
+
+Z Asia/Kolkata 5:53:28 - LMT 1854 Jun 28
+5:53:20 - HMT 1870
+5:21:10 - MMT 1906
+5:30 - IST 1941 O
+5:30 1 +0630 1942 May 15
+5:30 - IST 1942 S
+5:30 1 +0630 1945 O 15
+5:30 - IST
+
+Zone	Etc/UTC		0	-	UTC
+Link	Etc/UTC				Etc/Universal
+Link	Etc/UTC				UTC
+Link	Etc/Universal			Zulu
+`
+
+func testSourceFor(t *testing.T, src string) *Source {
+	var s Source
+	if err := s.Parse("test", strings.NewReader(src)); err != nil {
+		t.Fatalf("error parsing source: %s", err)
+	}
+	return &s
+}
+
+func TestCompile_Embedded(t *testing.T) {
+	s := testSourceFor(t, testSource)
+	for _, name := range []string{"America/New_York", "US/Eastern", "Asia/Kolkata", "Etc/UTC", "UTC", "Zulu"} {
+		l, err := s.CompileWithOptions(name, CompileOptions{Fat: true})
+		if err != nil {
+			t.Fatalf("%s: error compiling: %s", name, err)
+		}
+		if l.Name != name {
+			t.Fatalf("%s: got name %s", name, l.Name)
+		}
+		got, err := l.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: error encoding: %s", name, err)
+		}
+		want, _ := TZData(name)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: compiled data differs from embedded data", name)
+		}
+	}
+}
+
+func TestCompile_Slim(t *testing.T) {
+	s := testSourceFor(t, testSource)
+	slim, err := s.Compile("America/New_York")
+	if err != nil {
+		t.Fatalf("error compiling: %s", err)
+	}
+	fat, err := s.CompileWithOptions("America/New_York", CompileOptions{Fat: true})
+	if err != nil {
+		t.Fatalf("error compiling: %s", err)
+	}
+	if slim.Extend != "EST5EDT,M3.2.0,M11.1.0" {
+		t.Fatalf("got extend %q", slim.Extend)
+	}
+	// The footer rule describes the transitions from 2007 on.
+	if n := len(slim.Tx); n == 0 || n >= len(fat.Tx) || slim.Tx[n-1].When >= 1199516400 {
+		t.Fatalf("got %d transitions, fat data has %d", n, len(fat.Tx))
+	}
+	for _, zone := range slim.Zone {
+		if zone.Isstd || zone.Isutc {
+			t.Fatalf("got indicators in slim data: %+v", zone)
+		}
+	}
+	if d := Diff(slim, fat, -1<<40, 1<<36); len(d) > 0 {
+		t.Fatalf("slim data differs from fat data: %v", d[0])
+	}
+}
+
+func TestCompile_Extend(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{"Zone A 14 - +14", "<+14>-14"},
+		{"Zone A -5:30 - %z", "<-0530>5:30"},
+		{
+			// Daylight saving time all year.
+			"Zone A 5:45 1 +0645",
+			"XXX-7:45<+0645>-6:45,0/0,J365/23",
+		},
+		{
+			// Negative saved time in winter, as in Europe/Dublin.
+			"Rule IE 1981 max - Mar lastSun 1:00u 0 -\n" +
+				"Rule IE 1996 max - Oct lastSun 1:00u -1:00 -\n" +
+				"Zone A 1:00 IE IST/GMT",
+			"IST-1GMT0,M10.5.0,M3.5.0/1",
+		},
+		{
+			// Transitions before midnight, as in America/Nuuk.
+			"R E 1981 ma - Mar lastSu 1u 1 S\n" +
+				"R E 1996 ma - O lastSu 1u 0 -\n" +
+				"Z A -2 E %z",
+			"<-02>2<-01>,M3.5.0/-1,M10.5.0/0",
+		},
+		{
+			// A rule that ends, as in Asia/Bishkek.
+			"Rule KG 1997 2005 - Mar lastSun 2:30 1:00 -\n" +
+				"Rule KG 1997 2004 - Oct lastSun 2:30 0 -\n" +
+				"Zone A 5:00 KG %z 2005 Aug 12\n" +
+				"6:00 - %z",
+			"<+06>-6",
+		},
+	}
+
+	for _, c := range cases {
+		l, err := testSourceFor(t, c.src).Compile("A")
+		if err != nil {
+			t.Fatalf("%q: error compiling: %s", c.src, err)
+		}
+		if l.Extend != c.want {
+			t.Fatalf("%q: got extend %q, want %q", c.src, l.Extend, c.want)
+		}
+		if _, err := ParseRule(l.Extend); err != nil {
+			t.Fatalf("%q: error parsing extend: %s", c.src, err)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	cases := []struct {
+		src     string
+		name    string
+		wantErr string
+	}{
+		{"Zone A 1 - A", "B", "no zone or link named B"},
+		{"Link A B", "B", "link B targets unknown zone A"},
+		{"Link A B\nLink B A", "A", "link A is part of a link cycle"},
+		{"Zone A 1 X A", "A", "test:1: unknown rule X"},
+		{"Zone A 1 - A%s", "A", "test:1: %s in ruleless zone"},
+		{"Rule X 2000 only - Feb 29 0 1 D\nRule X 2001 only - Feb 29 0 0 S\nZone A 1 X A%sT", "A", "test:2: use of 2/29 in non leap-year"},
+	}
+
+	for _, c := range cases {
+		_, err := testSourceFor(t, c.src).Compile(c.name)
+		if err == nil || err.Error() != c.wantErr {
+			t.Fatalf("%q: got error %v, want %s", c.src, err, c.wantErr)
+		}
+	}
+}
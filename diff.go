@@ -0,0 +1,179 @@
+package tz
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DiffKind is the kind of a Difference.
+type DiffKind int
+
+const (
+	// DiffTransitionAdded is a transition b has and a doesn't.
+	DiffTransitionAdded DiffKind = iota
+	// DiffTransitionRemoved is a transition a has and b doesn't.
+	DiffTransitionRemoved
+	// DiffZone is a period during which a and b use different zones.
+	DiffZone
+)
+
+// Difference is a difference between the behaviour of two locations.
+type Difference struct {
+	Kind DiffKind
+
+	// Transition is the added or removed transition.
+	Transition Transition
+
+	// Start and End are the period, Start <= sec < End, during which
+	// a uses zone A and b uses zone B, for a DiffZone difference.
+	Start, End int64
+	A, B       Zone
+}
+
+// OffsetChanged reports whether the zones of a DiffZone difference
+// have different offsets.
+func (d Difference) OffsetChanged() bool {
+	return d.A.Offset != d.B.Offset
+}
+
+// NameChanged reports whether the zones of a DiffZone difference
+// have different abbreviations.
+func (d Difference) NameChanged() bool {
+	return d.A.Name != d.B.Name
+}
+
+// DSTChanged reports whether only one of the zones of a DiffZone
+// difference is daylight saving time.
+func (d Difference) DSTChanged() bool {
+	return d.A.IsDST != d.B.IsDST
+}
+
+func (d Difference) String() string {
+	switch d.Kind {
+	case DiffTransitionAdded, DiffTransitionRemoved:
+		what := "added"
+		if d.Kind == DiffTransitionRemoved {
+			what = "removed"
+		}
+		return fmt.Sprintf("%s: transition %s from %s to %s",
+			formatInstant(d.Transition.When), what, formatZone(d.Transition.Prev), formatZone(d.Transition.Next))
+	}
+	var changed []string
+	if d.NameChanged() {
+		changed = append(changed, "abbreviation")
+	}
+	if d.OffsetChanged() {
+		changed = append(changed, "offset")
+	}
+	if d.DSTChanged() {
+		changed = append(changed, "daylight saving time")
+	}
+	return fmt.Sprintf("%s to %s: %s changed from %s to %s",
+		formatInstant(d.Start), formatInstant(d.End), strings.Join(changed, ", "), formatZone(d.A), formatZone(d.B))
+}
+
+// Diff returns the differences between the zones locations a and b use
+// at the instants from <= sec < to, in seconds since January 1, 1970 UTC,
+// in order. Locations that use the same zones at every instant have no
+// differences, even if they are encoded differently.
+//
+// Zones are compared by name, offset and daylight saving time.
+// A transition that is at different instants in a and b is reported
+// as removed and added, with the period between them.
+func Diff(a, b *Location, from, to int64) []Difference {
+	if from >= to {
+		return nil
+	}
+	ta := a.Transitions(from, to)
+	tb := b.Transitions(from, to)
+
+	var diffs []Difference
+	instants := []int64{from}
+	atA := make(map[int64]Transition)
+	for _, tr := range ta {
+		atA[tr.When] = tr
+		instants = append(instants, tr.When)
+	}
+	atB := make(map[int64]Transition)
+	for _, tr := range tb {
+		atB[tr.When] = tr
+		instants = append(instants, tr.When)
+	}
+	sort.Slice(instants, func(i, j int) bool { return instants[i] < instants[j] })
+
+	za, _, _ := a.Lookup(from)
+	zb, _, _ := b.Lookup(from)
+	var zone *Difference
+	for i, sec := range instants {
+		if i > 0 && sec == instants[i-1] {
+			continue
+		}
+		trA, okA := atA[sec]
+		trB, okB := atB[sec]
+		if okA {
+			za = trA.Next
+		}
+		if okB {
+			zb = trB.Next
+		}
+
+		if okA && !okB {
+			diffs = append(diffs, Difference{Kind: DiffTransitionRemoved, Transition: trA})
+		}
+		if okB && !okA {
+			diffs = append(diffs, Difference{Kind: DiffTransitionAdded, Transition: trB})
+		}
+
+		if zone != nil && (sameZone(za, zone.A) && sameZone(zb, zone.B)) {
+			continue
+		}
+		if zone != nil {
+			zone.End = sec
+			diffs = append(diffs, *zone)
+			zone = nil
+		}
+		if !sameZone(za, zb) {
+			zone = &Difference{Kind: DiffZone, Start: sec, A: za, B: zb}
+		}
+	}
+	if zone != nil {
+		zone.End = to
+		diffs = append(diffs, *zone)
+	}
+	sort.SliceStable(diffs, func(i, j int) bool { return diffs[i].start() < diffs[j].start() })
+	return diffs
+}
+
+// start returns the instant the difference starts at.
+func (d Difference) start() int64 {
+	if d.Kind == DiffZone {
+		return d.Start
+	}
+	return d.Transition.When
+}
+
+// formatInstant formats seconds since January 1, 1970 UTC as a UTC time.
+func formatInstant(sec int64) string {
+	return time.Unix(sec, 0).UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+// formatZone formats a zone as its abbreviation and offset,
+// such as "CEST (+02:00 DST)".
+func formatZone(z Zone) string {
+	off := z.Offset
+	sign := '+'
+	if off < 0 {
+		sign = '-'
+		off = -off
+	}
+	s := fmt.Sprintf("%s (%c%02d:%02d", z.Name, sign, off/secondsPerHour, off/secondsPerMinute%60)
+	if off%secondsPerMinute != 0 {
+		s += fmt.Sprintf(":%02d", off%secondsPerMinute)
+	}
+	if z.IsDST {
+		s += " DST"
+	}
+	return s + ")"
+}
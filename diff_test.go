@@ -0,0 +1,103 @@
+package tz
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiff_Same(t *testing.T) {
+	l, err := locationByName("America/New_York")
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	from := time.Date(1800, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+	// The same behaviour, encoded differently.
+	others := []*Location{
+		l.Normalize(NormalizeOptions{MergeZones: true, DropRedundant: true, DropUnused: true}),
+		l.ExtendTo(2100),
+		l.Slice(from, to),
+	}
+	for i, other := range others {
+		if diffs := Diff(l, other, from, to); len(diffs) != 0 {
+			t.Fatalf("location %d: got differences %v", i, diffs)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	l, err := locationByName("America/New_York")
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	est := Zone{Name: "EST", Offset: -5 * 3600}
+	edt := Zone{Name: "EDT", Offset: -4 * 3600, IsDST: true}
+
+	// Move the 2019 spring transition a week later,
+	// and use a different abbreviation for 2020 winter.
+	spring := time.Date(2019, time.March, 10, 7, 0, 0, 0, time.UTC).Unix()
+	fall := time.Date(2020, time.November, 1, 6, 0, 0, 0, time.UTC).Unix()
+	spring2021 := time.Date(2021, time.March, 14, 7, 0, 0, 0, time.UTC).Unix()
+	patched := l.clone()
+	xst := patched.zoneIndex(Zone{Name: "XST", Offset: -5 * 3600})
+	for i := range patched.Tx {
+		switch patched.Tx[i].When {
+		case spring:
+			patched.Tx[i].When += 7 * 86400
+		case fall:
+			patched.Tx[i].Index = xst
+		}
+	}
+
+	from := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	diffs := Diff(l, patched, from, to)
+	want := []Difference{
+		{Kind: DiffTransitionRemoved, Transition: Transition{When: spring, Prev: est, Next: edt}},
+		{Kind: DiffZone, Start: spring, End: spring + 7*86400, A: edt, B: est},
+		{Kind: DiffTransitionAdded, Transition: Transition{When: spring + 7*86400, Prev: est, Next: edt}},
+		{Kind: DiffZone, Start: fall, End: spring2021, A: est, B: Zone{Name: "XST", Offset: -5 * 3600}},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("got %d differences, want %d: %v", len(diffs), len(want), diffs)
+	}
+	for i := range want {
+		got := diffs[i]
+		if got.Kind != want[i].Kind || got.Start != want[i].Start || got.End != want[i].End ||
+			got.Transition.When != want[i].Transition.When ||
+			!sameZone(got.Transition.Prev, want[i].Transition.Prev) || !sameZone(got.Transition.Next, want[i].Transition.Next) ||
+			!sameZone(got.A, want[i].A) || !sameZone(got.B, want[i].B) {
+			t.Fatalf("difference %d: got %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	wantStrings := []string{
+		"2019-03-10 07:00:00 UTC: transition removed from EST (-05:00) to EDT (-04:00 DST)",
+		"2019-03-10 07:00:00 UTC to 2019-03-17 07:00:00 UTC: abbreviation, offset, daylight saving time changed from EDT (-04:00 DST) to EST (-05:00)",
+		"2019-03-17 07:00:00 UTC: transition added from EST (-05:00) to EDT (-04:00 DST)",
+		"2020-11-01 06:00:00 UTC to 2021-03-14 07:00:00 UTC: abbreviation changed from EST (-05:00) to XST (-05:00)",
+	}
+	for i, want := range wantStrings {
+		if got := diffs[i].String(); got != want {
+			t.Fatalf("difference %d: got %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestFormatZone(t *testing.T) {
+	cases := []struct {
+		zone Zone
+		want string
+	}{
+		{Zone{Name: "UTC"}, "UTC (+00:00)"},
+		{Zone{Name: "CEST", Offset: 7200, IsDST: true}, "CEST (+02:00 DST)"},
+		{Zone{Name: "LMT", Offset: -(4*3600 + 56*60 + 2)}, "LMT (-04:56:02)"},
+		{Zone{Name: "+0530", Offset: 5*3600 + 1800}, "+0530 (+05:30)"},
+	}
+	for _, c := range cases {
+		if got := formatZone(c.zone); got != c.want {
+			t.Errorf("got %s, want %s", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package tz
+
+import "time"
+
+// ExtendTo returns a copy of the location with the transitions described
+// by its footer rule added after the last transition,
+// up to the end of the given year.
+//
+// Instants covered by the added transitions resolve to the same zones
+// as they do in a time.Location loaded from the same data.
+// If the location has no footer rule, the copy has no added transitions.
+// If the location has no transitions other than the one covering all time,
+// the added transitions start in 1970. Transitions are only added for
+// the years 1 to 9999.
+func (l *Location) ExtendTo(year int) *Location {
+	ext := l.clone()
+	if l.Rule == nil || len(l.Tx) == 0 {
+		return ext
+	}
+
+	last := l.Tx[len(l.Tx)-1]
+	from := 1970
+	if last.When != alpha {
+		from = time.Unix(last.When, 0).UTC().Year()
+	}
+	if from < minRuleYear {
+		from = minRuleYear
+	}
+	if year > maxRuleYear {
+		year = maxRuleYear
+	}
+	prev := l.Zone[last.Index]
+	for y := from; y <= year; y++ {
+		for _, s := range l.Rule.spans(y) {
+			if s.start <= last.When || sameZone(s.zone, prev) {
+				continue
+			}
+			i := ext.zoneIndex(s.zone)
+			ext.Tx = append(ext.Tx, ZoneTrans{
+				When:  s.start,
+				Index: i,
+				Isstd: ext.Zone[i].Isstd,
+				Isutc: ext.Zone[i].Isutc,
+			})
+			prev = s.zone
+		}
+	}
+	return ext
+}
+
+// clone returns a copy of the location that doesn't share
+// its zones and transitions with l.
+func (l *Location) clone() *Location {
+	c := *l
+	c.Zone = append([]Zone(nil), l.Zone...)
+	c.Tx = append([]ZoneTrans(nil), l.Tx...)
+	return &c
+}
+
+// zoneIndex returns the index of the zone in l.Zone,
+// appending it if it is not there yet.
+func (l *Location) zoneIndex(zone Zone) uint8 {
+	for i := range l.Zone {
+		if sameZone(l.Zone[i], zone) {
+			return uint8(i)
+		}
+	}
+	l.Zone = append(l.Zone, zone)
+	return uint8(len(l.Zone) - 1)
+}
+
+// sameZone reports whether a and b describe the same local time.
+func sameZone(a, b Zone) bool {
+	return a.Name == b.Name && a.Offset == b.Offset && a.IsDST == b.IsDST
+}
@@ -0,0 +1,140 @@
+package tz
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// zoneAt returns the zone in effect at sec according to l.Tx only.
+func zoneAt(l *Location, sec int64) Zone {
+	i := len(l.Tx) - 1
+	for i > 0 && l.Tx[i].When > sec {
+		i--
+	}
+	return l.Zone[l.Tx[i].Index]
+}
+
+func TestExtendTo(t *testing.T) {
+	cases := []struct {
+		name string
+	}{
+		{"America/New_York"},
+		{"Australia/Sydney"},
+		{"Europe/London"},
+		{"Asia/Jerusalem"},
+		{"America/Godthab"},
+		{"America/Santiago"},
+		{"Pacific/Auckland"},
+		{"Etc/GMT+5"},
+	}
+
+	from := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2100, time.December, 31, 0, 0, 0, 0, time.UTC).Unix()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			locData, ok := TZData(c.name)
+			if !ok {
+				t.Fatalf("error loading timezone data")
+			}
+			l, err := ParseLocation(c.name, locData)
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			stdlibLoc, err := time.LoadLocationFromTZData(c.name, locData)
+			if err != nil {
+				t.Fatalf("error loading stdlib location: %s", err)
+			}
+
+			ext := l.ExtendTo(2100)
+			if len(ext.Tx) < len(l.Tx) {
+				t.Fatalf("got %d transitions, want at least %d", len(ext.Tx), len(l.Tx))
+			}
+
+			check := func(sec int64) {
+				got := zoneAt(ext, sec)
+				name, offset := time.Unix(sec, 0).In(stdlibLoc).Zone()
+				if got.Name != name || got.Offset != offset {
+					t.Fatalf("at %d: got %s %d, want %s %d", sec, got.Name, got.Offset, name, offset)
+				}
+			}
+			for sec := from; sec < to; sec += 6 * 3600 {
+				check(sec)
+			}
+			for _, tx := range ext.Tx[len(l.Tx):] {
+				check(tx.When - 1)
+				check(tx.When)
+			}
+		})
+	}
+}
+
+func TestExtendTo_Slim(t *testing.T) {
+	name := "America/New_York"
+	locData, ok := TZData(name)
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+	l, err := ParseLocation(name, locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	stdlibLoc, err := time.LoadLocationFromTZData(name, locData)
+	if err != nil {
+		t.Fatalf("error loading stdlib location: %s", err)
+	}
+
+	// Drop the transitions after 2007, when the current rule started,
+	// like zic does for slim files.
+	cutoff := time.Date(2008, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	slim := *l
+	slim.Tx = nil
+	for _, tx := range l.Tx {
+		if tx.When < cutoff {
+			slim.Tx = append(slim.Tx, tx)
+		}
+	}
+
+	ext := slim.ExtendTo(2037)
+	if len(ext.Tx) != len(l.Tx) {
+		t.Fatalf("got %d transitions, want %d", len(ext.Tx), len(l.Tx))
+	}
+	for i := range ext.Tx {
+		if ext.Tx[i].When != l.Tx[i].When {
+			t.Fatalf("transition %d: got %d, want %d", i, ext.Tx[i].When, l.Tx[i].When)
+		}
+	}
+	for sec := cutoff; sec < cutoff+30*365*86400; sec += 3600 {
+		got := zoneAt(ext, sec)
+		name, offset := time.Unix(sec, 0).In(stdlibLoc).Zone()
+		if got.Name != name || got.Offset != offset {
+			t.Fatalf("at %d: got %s %d, want %s %d", sec, got.Name, got.Offset, name, offset)
+		}
+	}
+}
+
+func TestExtendTo_FarPast(t *testing.T) {
+	r, err := ParseRule("EST5EDT,M3.2.0,M11.1.0")
+	if err != nil {
+		t.Fatalf("error parsing rule: %s", err)
+	}
+	for _, when := range []int64{-1 << 40, -1 << 59} {
+		l := &Location{
+			Zone: []Zone{{Name: "EST", Offset: -5 * 3600}},
+			Tx:   []ZoneTrans{{When: when, Index: 0}},
+			Rule: r,
+		}
+		ext := l.ExtendTo(2100)
+		// At most two transitions a year from year 1 on.
+		if n := len(ext.Tx) - len(l.Tx); n == 0 || n > 2*2100 {
+			t.Fatalf("last transition at %d: got %d added transitions", when, n)
+		}
+		if first := ext.Tx[1].When; first < yearStart(minRuleYear) {
+			t.Fatalf("last transition at %d: got added transition at %d before year %d", when, first, minRuleYear)
+		}
+		if got := l.ExtendTo(math.MaxInt); len(got.Tx)-len(l.Tx) > 2*maxRuleYear {
+			t.Fatalf("last transition at %d: got %d added transitions up to the last year", when, len(got.Tx)-len(l.Tx))
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package tz
+
+import (
+	"sort"
+	"sync"
+)
+
+// link is a Link line of the time zone database.
+type link struct {
+	target string
+	// backward is true for links in the backward file,
+	// which are kept for compatibility and are deprecated.
+	backward bool
+}
+
+var (
+	dataLinksOnce sync.Once
+	dataLinks     map[string]string
+)
+
+// Canonical returns the canonical name of the embedded location with the
+// given name: the name of the zone it links to, or name if it's a zone.
+// It returns false if there is no embedded location with the name.
+//
+// Links are looked up in the links of the time zone database.
+// An embedded location that's not in them, but has the same data as
+// a zone, is treated as a link to that zone.
+func Canonical(name string) (string, bool) {
+	if _, ok := TZData(name); !ok {
+		return "", false
+	}
+	if l, ok := lookupLink(name); ok {
+		return l.target, true
+	}
+	dataLinksOnce.Do(func() {
+		dataLinks = identicalLinks(allLinks())
+	})
+	if target, ok := dataLinks[name]; ok {
+		return target, true
+	}
+	return name, true
+}
+
+// IsLink reports whether the embedded location with the given name
+// is a link to another zone.
+func IsLink(name string) bool {
+	canonical, ok := Canonical(name)
+	return ok && canonical != name
+}
+
+// IsDeprecated reports whether the embedded location with the given name
+// is a link from the backward file of the time zone database, kept for
+// compatibility with old names.
+func IsDeprecated(name string) bool {
+	l, _ := lookupLink(name)
+	return l.backward
+}
+
+// lookupLink returns the link with the given name
+// from the links of the time zone database.
+func lookupLink(name string) (link, bool) {
+	i := sort.SearchStrings(linkNames, name)
+	if i == len(linkNames) || linkNames[i] != name {
+		return link{}, false
+	}
+	return link{linkTargets[i], linkBackward[i]}, true
+}
+
+// allLinks returns the links of the time zone database, by name.
+func allLinks() map[string]link {
+	table := make(map[string]link, len(linkNames))
+	for i, name := range linkNames {
+		table[name] = link{linkTargets[i], linkBackward[i]}
+	}
+	return table
+}
+
+// Aliases returns the names of the embedded locations that link to the
+// same zone as the location with the given name, other than the zone
+// itself, sorted.
+func Aliases(name string) []string {
+	canonical, ok := Canonical(name)
+	if !ok {
+		return nil
+	}
+	var aliases []string
+	for _, n := range Names() {
+		if c, _ := Canonical(n); c == canonical && n != canonical {
+			aliases = append(aliases, n)
+		}
+	}
+	return aliases
+}
+
+// identicalLinks returns links from the embedded locations that are not
+// in table to the zone with the same data. The zone is a link target in
+// table, if there is one, or the first of the locations with the data.
+func identicalLinks(table map[string]link) map[string]string {
+	targets := make(map[string]bool)
+	for _, l := range table {
+		targets[l.target] = true
+	}
+	// Locations with the same data share a payload.
+	groups := make(map[uint16][]string)
+	for i, name := range zoneNames {
+		if _, ok := table[name]; ok {
+			continue
+		}
+		p := zonePayloads[i]
+		groups[p] = append(groups[p], name)
+	}
+
+	dataLinks := make(map[string]string)
+	for _, names := range groups {
+		if len(names) < 2 {
+			continue
+		}
+		sort.Strings(names)
+		target := names[0]
+		for _, name := range names {
+			if targets[name] {
+				target = name
+				break
+			}
+		}
+		for _, name := range names {
+			if name != target {
+				dataLinks[name] = target
+			}
+		}
+	}
+	return dataLinks
+}
@@ -0,0 +1,102 @@
+package tz
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLinks(t *testing.T) {
+	if !sort.StringsAreSorted(linkNames) || len(linkTargets) != len(linkNames) || len(linkBackward) != len(linkNames) {
+		t.Fatalf("link table is not sorted parallel slices")
+	}
+	links := allLinks()
+	for name, l := range links {
+		data, ok := TZData(name)
+		if !ok {
+			t.Fatalf("%s: no data", name)
+		}
+		targetData, ok := TZData(l.target)
+		if !ok {
+			t.Fatalf("%s: no data for target %s", name, l.target)
+		}
+		if !bytes.Equal(data, targetData) {
+			t.Fatalf("%s: data differs from target %s", name, l.target)
+		}
+		if _, ok := links[l.target]; ok {
+			t.Fatalf("%s: target %s is a link", name, l.target)
+		}
+	}
+	if dataLinks := identicalLinks(links); len(dataLinks) != 0 {
+		t.Fatalf("links missing from the table: %v", dataLinks)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	cases := []struct {
+		name       string
+		canonical  string
+		link       bool
+		deprecated bool
+	}{
+		{"America/New_York", "America/New_York", false, false},
+		{"US/Eastern", "America/New_York", true, true},
+		{"Africa/Asmara", "Africa/Nairobi", true, false},
+		{"Africa/Asmera", "Africa/Nairobi", true, true},
+		{"UTC", "Etc/UTC", true, true},
+		{"Etc/UTC", "Etc/UTC", false, false},
+	}
+
+	for _, c := range cases {
+		canonical, ok := Canonical(c.name)
+		if !ok || canonical != c.canonical {
+			t.Errorf("%s: got canonical %s %t, want %s", c.name, canonical, ok, c.canonical)
+		}
+		if got := IsLink(c.name); got != c.link {
+			t.Errorf("%s: got link %t, want %t", c.name, got, c.link)
+		}
+		if got := IsDeprecated(c.name); got != c.deprecated {
+			t.Errorf("%s: got deprecated %t, want %t", c.name, got, c.deprecated)
+		}
+	}
+
+	if _, ok := Canonical("not-a-real-location"); ok {
+		t.Errorf("got canonical name for unknown location")
+	}
+	if IsLink("not-a-real-location") {
+		t.Errorf("unknown location is a link")
+	}
+}
+
+func TestAliases(t *testing.T) {
+	if got := Aliases("EST5EDT"); got != nil {
+		t.Errorf("got aliases %v for a zone without links", got)
+	}
+	want := []string{"America/Fort_Wayne", "America/Indianapolis", "US/East-Indiana"}
+	for _, name := range []string{"America/Indiana/Indianapolis", "US/East-Indiana"} {
+		if got := Aliases(name); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got aliases %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestIdenticalLinks(t *testing.T) {
+	table := allLinks()
+	delete(table, "US/Eastern")
+	delete(table, "Europe/Vatican")
+	delete(table, "Europe/San_Marino")
+
+	got := identicalLinks(table)
+	want := map[string]string{
+		// America/New_York is still a link target.
+		"US/Eastern": "America/New_York",
+		// No link in the table targets Europe/Rome,
+		// it's the first of the names with its data.
+		"Europe/Vatican":    "Europe/Rome",
+		"Europe/San_Marino": "Europe/Rome",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
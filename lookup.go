@@ -0,0 +1,146 @@
+package tz
+
+import "time"
+
+// Lookup returns the zone in use at an instant in time expressed
+// as seconds since January 1, 1970 00:00:00 UTC, and the start and end
+// of the period the zone is in use, start <= sec < end.
+//
+// Lookup follows time.Location: before the first transition it uses
+// the zone a time.Location would use, and after the last transition
+// it uses the footer rule, if there is one. Unlike time.Location, the
+// period of a zone from the footer rule ends at the next transition of
+// the rule, not at the end of the year. A location without zones
+// is UTC. The start and end are alpha and omega, math.MinInt64 and
+// math.MaxInt64, if the period has no beginning or end.
+func (l *Location) Lookup(sec int64) (zone Zone, start, end int64) {
+	if len(l.Zone) == 0 {
+		return Zone{Name: "UTC"}, alpha, omega
+	}
+
+	if len(l.Tx) == 0 || sec < l.Tx[0].When {
+		zone = l.Zone[l.lookupFirstZone()]
+		start = alpha
+		if len(l.Tx) > 0 {
+			end = l.Tx[0].When
+		} else {
+			end = omega
+		}
+		return zone, start, end
+	}
+
+	// Binary search for entry with largest time <= sec.
+	tx := l.Tx
+	end = omega
+	lo := 0
+	hi := len(tx)
+	for hi-lo > 1 {
+		m := int(uint(lo+hi) >> 1)
+		lim := tx[m].When
+		if sec < lim {
+			end = lim
+			hi = m
+		} else {
+			lo = m
+		}
+	}
+	zone = l.Zone[tx[lo].Index]
+	start = tx[lo].When
+
+	// If we're at the end of the known zone transitions,
+	// try the footer rule.
+	if lo == len(tx)-1 && l.Rule != nil {
+		return l.Rule.lookup(start, sec)
+	}
+
+	return zone, start, end
+}
+
+// lookupFirstZone returns the index of the time zone to use for times
+// before the first transition time, or when there are no transition
+// times.
+//
+// The reference implementation in localtime.c from
+// https://www.iana.org/time-zones/repository/releases/tzcode2013g.tar.gz
+// implements the following algorithm for these cases:
+//  1. If the first zone is unused by the transitions, use it.
+//  2. Otherwise, if there are transition times, and the first
+//     transition is to a zone in daylight time, find the first
+//     non-daylight-time zone before and closest to the first transition
+//     zone.
+//  3. Otherwise, use the first zone that is not daylight time, if
+//     there is one.
+//  4. Otherwise, use the first zone.
+func (l *Location) lookupFirstZone() int {
+	// Case 1.
+	if !l.firstZoneUsed() {
+		return 0
+	}
+
+	// Case 2.
+	if len(l.Tx) > 0 && l.Zone[l.Tx[0].Index].IsDST {
+		for zi := int(l.Tx[0].Index) - 1; zi >= 0; zi-- {
+			if !l.Zone[zi].IsDST {
+				return zi
+			}
+		}
+	}
+
+	// Case 3.
+	for zi := range l.Zone {
+		if !l.Zone[zi].IsDST {
+			return zi
+		}
+	}
+
+	// Case 4.
+	return 0
+}
+
+// firstZoneUsed reports whether the first zone is used by some
+// transition.
+func (l *Location) firstZoneUsed() bool {
+	for _, tx := range l.Tx {
+		if tx.Index == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the zone the rule puts in use at sec, and the start and
+// end of the period it is in use. The rule is in effect from lastTx on.
+func (r *Rule) lookup(lastTx, sec int64) (zone Zone, start, end int64) {
+	if !r.HasDST {
+		return r.Std, lastTx, omega
+	}
+
+	year := time.Unix(sec, 0).UTC().Year()
+	spans := r.spans(year)
+	if len(spans) == 0 {
+		// sec is too far from the present for time.Time.
+		return r.Std, lastTx, omega
+	}
+	i := 0
+	for i < len(spans)-1 && spans[i].end <= sec {
+		i++
+	}
+	s := spans[i]
+
+	// The periods at the start and end of the year
+	// usually continue in the previous and next year.
+	if i == 0 {
+		if prev := r.spans(year - 1); len(prev) > 0 && sameZone(prev[len(prev)-1].zone, s.zone) {
+			s.start = prev[len(prev)-1].start
+		}
+	}
+	if i == len(spans)-1 {
+		if next := r.spans(year + 1); len(next) > 0 && sameZone(next[0].zone, s.zone) {
+			s.end = next[0].end
+		}
+	}
+	if s.start < lastTx {
+		s.start = lastTx
+	}
+	return s.zone, s.start, s.end
+}
@@ -0,0 +1,101 @@
+package tz
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLookup(t *testing.T) {
+	for _, name := range zoneNames {
+		t.Run(name, func(t *testing.T) {
+			locData, ok := TZData(name)
+			if !ok {
+				t.Fatalf("error loading timezone data")
+			}
+			l, err := ParseLocation(name, locData)
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			stdlibLoc, err := time.LoadLocationFromTZData(name, locData)
+			if err != nil {
+				t.Fatalf("error loading stdlib location: %s", err)
+			}
+
+			check := func(sec int64) {
+				zone, start, end := l.Lookup(sec)
+				tm := time.Unix(sec, 0).In(stdlibLoc)
+				wantName, wantOffset := tm.Zone()
+				if zone.Name != wantName || zone.Offset != wantOffset || zone.IsDST != tm.IsDST() {
+					t.Fatalf("at %d: got %s %d %t, want %s %d %t", sec, zone.Name, zone.Offset, zone.IsDST, wantName, wantOffset, tm.IsDST())
+				}
+				if start > sec || sec >= end {
+					t.Fatalf("at %d: got period %d to %d", sec, start, end)
+				}
+				if start != alpha {
+					if z, _, _ := l.Lookup(start); z != zone {
+						t.Fatalf("at %d: zone at start %d is %s, want %s", sec, start, z.Name, zone.Name)
+					}
+					if _, _, prevEnd := l.Lookup(start - 1); prevEnd != start {
+						t.Fatalf("at %d: period before start %d ends at %d", sec, start, prevEnd)
+					}
+				}
+				if end != omega {
+					if z, _, _ := l.Lookup(end - 1); z != zone {
+						t.Fatalf("at %d: zone before end %d is %s, want %s", sec, end, z.Name, zone.Name)
+					}
+					if _, nextStart, _ := l.Lookup(end); nextStart != end {
+						t.Fatalf("at %d: period after end %d starts at %d", sec, end, nextStart)
+					}
+				}
+			}
+
+			check(-1 << 62)
+			check(1 << 62)
+			for _, tx := range l.Tx {
+				if tx.When != alpha {
+					check(tx.When - 1)
+					check(tx.When)
+				}
+			}
+			// Past the last transition, the footer rule applies.
+			for sec := int64(2e9); sec < 5e9; sec += 7 * 86400 {
+				check(sec)
+			}
+		})
+	}
+}
+
+func TestLookup_NoZones(t *testing.T) {
+	var l Location
+	zone, start, end := l.Lookup(0)
+	if zone.Name != "UTC" || zone.Offset != 0 || start != alpha || end != omega {
+		t.Fatalf("got %s %d %d %d", zone.Name, zone.Offset, start, end)
+	}
+}
+
+func TestLookup_RangeEdges(t *testing.T) {
+	locData, ok := TZData("Europe/Budapest")
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+	l, err := ParseLocation("Europe/Budapest", locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	if l.Rule == nil {
+		t.Fatalf("location has no footer rule")
+	}
+
+	zone, start, end := l.Lookup(math.MinInt64)
+	if want := l.Zone[l.lookupFirstZone()]; zone != want || start != alpha || end != l.Tx[0].When {
+		t.Errorf("at MinInt64: got %s %d to %d, want %s %d to %d", zone.Name, start, end, want.Name, alpha, l.Tx[0].When)
+	}
+
+	// Past the range of time.Time the footer rule falls back to
+	// standard time.
+	zone, start, end = l.Lookup(math.MaxInt64)
+	if zone != l.Rule.Std || start < l.Tx[len(l.Tx)-1].When || end != omega {
+		t.Errorf("at MaxInt64: got %s %d to %d, want %s to %d", zone.Name, start, end, l.Rule.Std.Name, omega)
+	}
+}
@@ -0,0 +1,153 @@
+package tz
+
+// NormalizeOptions selects the changes Location.Normalize makes.
+type NormalizeOptions struct {
+	// MergeZones merges zones with the same name, offset and
+	// daylight saving time into the first of them.
+	MergeZones bool
+
+	// DropRedundant removes transitions to the zone
+	// that is already in effect.
+	DropRedundant bool
+
+	// DropUnused removes zones no transition uses, except the zone
+	// in effect before the first transition.
+	DropUnused bool
+
+	// ReplaceLMT replaces Local Mean Time zones with the first standard
+	// time zone that follows them.
+	ReplaceLMT bool
+}
+
+// Normalize returns a copy of the location changed as selected by opts.
+// Apart from Local Mean Time, if it's replaced, every instant resolves
+// to the same zone in the copy as in l.
+func (l *Location) Normalize(opts NormalizeOptions) *Location {
+	n := l.clone()
+	// The zone order may change, the abbreviations of the parsed data
+	// no longer apply.
+	n.abbrev = ""
+	if len(n.Zone) == 0 {
+		return n
+	}
+	first, _, _ := n.Lookup(alpha)
+
+	if opts.ReplaceLMT {
+		if std, ok := n.firstStd(); ok {
+			for i := range n.Zone {
+				if n.Zone[i].Name == "LMT" {
+					n.Zone[i] = std
+				}
+			}
+			if first.Name == "LMT" {
+				first = std
+			}
+		}
+	}
+	if opts.MergeZones {
+		index := make([]uint8, len(n.Zone))
+		var zones []Zone
+		for i, zone := range n.Zone {
+			j := 0
+			for j < len(zones) && !sameZone(zones[j], zone) {
+				j++
+			}
+			if j == len(zones) {
+				zones = append(zones, zone)
+			}
+			index[i] = uint8(j)
+		}
+		n.Zone = zones
+		for i := range n.Tx {
+			n.Tx[i].Index = index[n.Tx[i].Index]
+		}
+	}
+	if opts.DropRedundant {
+		tx := n.Tx[:0]
+		prev := first
+		for _, t := range n.Tx {
+			zone := n.Zone[t.Index]
+			if t.When == alpha || !sameZone(zone, prev) {
+				tx = append(tx, t)
+			}
+			prev = zone
+		}
+		n.Tx = tx
+	}
+	if opts.DropUnused {
+		used := make([]bool, len(n.Zone))
+		for _, t := range n.Tx {
+			used[t.Index] = true
+		}
+		index := make([]uint8, len(n.Zone))
+		var zones []Zone
+		for i, zone := range n.Zone {
+			if used[i] || sameZone(zone, first) && !containsZone(zones, first) {
+				index[i] = uint8(len(zones))
+				zones = append(zones, zone)
+			}
+		}
+		n.Zone = zones
+		for i := range n.Tx {
+			n.Tx[i].Index = index[n.Tx[i].Index]
+		}
+	}
+
+	n.keepFirstZone(first)
+	return n
+}
+
+// firstStd returns the zone of the first transition to a standard time
+// zone other than Local Mean Time.
+func (l *Location) firstStd() (Zone, bool) {
+	for _, t := range l.Tx {
+		if zone := l.Zone[t.Index]; !zone.IsDST && zone.Name != "LMT" {
+			return zone, true
+		}
+	}
+	for _, zone := range l.Zone {
+		if !zone.IsDST && zone.Name != "LMT" {
+			return zone, true
+		}
+	}
+	return Zone{}, false
+}
+
+// keepFirstZone makes sure that first is the zone in effect before the
+// first transition, moving it to the front of the zones, or adding a
+// transition covering all time, if needed.
+func (l *Location) keepFirstZone(first Zone) {
+	if zone, _, _ := l.Lookup(alpha); sameZone(zone, first) {
+		return
+	}
+	i := 0
+	for i < len(l.Zone) && !sameZone(l.Zone[i], first) {
+		i++
+	}
+	if i == len(l.Zone) {
+		l.Zone = append(l.Zone, first)
+	}
+	l.Zone[0], l.Zone[i] = l.Zone[i], l.Zone[0]
+	for j := range l.Tx {
+		switch int(l.Tx[j].Index) {
+		case 0:
+			l.Tx[j].Index = uint8(i)
+		case i:
+			l.Tx[j].Index = 0
+		}
+	}
+	if zone, _, _ := l.Lookup(alpha); sameZone(zone, first) {
+		return
+	}
+	l.Tx = append([]ZoneTrans{{When: alpha, Index: 0}}, l.Tx...)
+}
+
+// containsZone reports whether zones has a zone that is the same as zone.
+func containsZone(zones []Zone, zone Zone) bool {
+	for _, z := range zones {
+		if sameZone(z, zone) {
+			return true
+		}
+	}
+	return false
+}
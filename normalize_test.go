@@ -0,0 +1,109 @@
+package tz
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	opts := NormalizeOptions{
+		MergeZones:    true,
+		DropRedundant: true,
+		DropUnused:    true,
+	}
+	for _, name := range zoneNames {
+		t.Run(name, func(t *testing.T) {
+			l, err := locationByName(name)
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			n := l.Normalize(opts)
+
+			for i := range n.Zone {
+				for j := 0; j < i; j++ {
+					if sameZone(n.Zone[i], n.Zone[j]) {
+						t.Fatalf("zones %d and %d are the same", j, i)
+					}
+				}
+			}
+			first, _, _ := n.Lookup(alpha)
+			used := make([]bool, len(n.Zone))
+			for i, tx := range n.Tx {
+				used[tx.Index] = true
+				if i > 0 && n.Tx[i-1].Index == tx.Index {
+					t.Fatalf("transition %d is redundant", i)
+				}
+			}
+			for i, zone := range n.Zone {
+				if !used[i] && !sameZone(zone, first) {
+					t.Fatalf("zone %d is unused", i)
+				}
+			}
+
+			stdlibLoc, err := n.TimeLocation()
+			if err != nil {
+				t.Fatalf("error converting location: %s", err)
+			}
+			check := func(sec int64) {
+				want, _, _ := l.Lookup(sec)
+				got, _, _ := n.Lookup(sec)
+				if !sameZone(got, want) {
+					t.Fatalf("at %d: got %v, want %v", sec, got, want)
+				}
+				if got := timeZone(stdlibLoc, sec); !sameZone(got, want) {
+					t.Fatalf("at %d: time.Location got %v, want %v", sec, got, want)
+				}
+			}
+			check(-1 << 40)
+			check(1 << 40)
+			for _, tx := range l.Tx {
+				if tx.When != alpha {
+					check(tx.When - 1)
+					check(tx.When)
+				}
+			}
+		})
+	}
+}
+
+func TestNormalize_ReplaceLMT(t *testing.T) {
+	name := "Europe/Budapest"
+	l, err := locationByName(name)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	n := l.Normalize(NormalizeOptions{
+		DropRedundant: true,
+		DropUnused:    true,
+		ReplaceLMT:    true,
+	})
+	for _, zone := range n.Zone {
+		if zone.Name == "LMT" {
+			t.Fatalf("got LMT zone %v", zone)
+		}
+	}
+	if zone, _, _ := n.Lookup(-3e9); zone.Name != "CET" || zone.Offset != 3600 {
+		t.Fatalf("got %v before the first transition, want CET", zone)
+	}
+	if len(n.Tx) != len(l.Tx)-1 {
+		t.Fatalf("got %d transitions, want %d", len(n.Tx), len(l.Tx)-1)
+	}
+	for sec := int64(-2e9); sec < 3e9; sec += 86400 {
+		want, _, _ := l.Lookup(sec)
+		if got, _, _ := n.Lookup(sec); !sameZone(got, want) {
+			t.Fatalf("at %d: got %v, want %v", sec, got, want)
+		}
+	}
+}
+
+func TestNormalize_Unchanged(t *testing.T) {
+	l, err := locationByName("America/New_York")
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	n := l.Normalize(NormalizeOptions{})
+	if len(n.Zone) != len(l.Zone) || len(n.Tx) != len(l.Tx) {
+		t.Fatalf("got %d zones and %d transitions, want %d and %d", len(n.Zone), len(n.Tx), len(l.Zone), len(l.Tx))
+	}
+	n.Tx[0].When = 0
+	if l.Tx[0].When == 0 {
+		t.Fatalf("normalized location shares transitions with the original")
+	}
+}
@@ -1,18 +1,39 @@
 package tz
 
-import "errors"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
 
 // Zone represents a single time zone such as CEST or CET.
 type Zone struct {
 	Name   string // abbreviated name, "CET"
 	Offset int    // seconds east of UTC
 	IsDST  bool   // is this zone Daylight Savings Time?
+	Isstd  bool   // are transitions into this zone in standard time, not wall clock time?
+	Isutc  bool   // are transitions into this zone in UT, not local time?
 }
 
 // ZoneTrans represents a single time zone transition.
+//
+// TZif data stores the standard/wall and UT/local indicators per zone,
+// so Isstd and Isutc are copies of the indicators of the zone at Index.
+// They are for reading only: WriteTZif uses the indicators of the zone.
 type ZoneTrans struct {
 	When  int64 // transition time, in seconds since 1970 GMT
 	Index uint8 // the index of the zone that goes into effect at that time
+	Isstd bool  // is the transition time in standard time, not wall clock time?
+	Isutc bool  // is the transition time in UT, not local time?
+}
+
+// LeapSecond represents a single leap second record.
+type LeapSecond struct {
+	When       int64 // time the correction occurs, in seconds since 1970 GMT including earlier leap seconds
+	Correction int64 // total correction in seconds after this time
 }
 
 // alpha and omega are the beginning and end of time for zone
@@ -28,25 +49,149 @@ type Location struct {
 	Name string
 	Zone []Zone
 	Tx   []ZoneTrans
+
+	// Version is the TZif format version, 1 to 4,
+	// of the data the location was parsed from.
+	Version int
+
+	// Extend is the POSIX TZ rule from the footer of version 2+ files,
+	// such as "EST5EDT,M3.2.0,M11.1.0". It describes the zone after
+	// the last transition. Rule is its parsed form, nil if Extend is empty.
+	Extend string
+	Rule   *Rule
+
+	// Leap contains the leap second records, as found in files
+	// such as those in the "right/" directory of the time zone database.
+	Leap []LeapSecond
+
+	// abbrev is the abbreviation table of the parsed data,
+	// used to write the abbreviations back in the same order.
+	abbrev string
 }
 
-// Simple I/O interface to binary blob of data.
+// LeapTruncated reports whether the leap second table was truncated at the
+// start, that is, whether its first correction is neither +1 nor -1.
+// Leap seconds before the first record are then unknown.
+func (l *Location) LeapTruncated() bool {
+	if len(l.Leap) == 0 {
+		return false
+	}
+	c := l.Leap[0].Correction
+	return c != 1 && c != -1
+}
+
+// LeapExpires returns the expiration time of the leap second table.
+// The last record marks the expiration if it has the same correction
+// as the one before it. The returned bool is false if the table
+// doesn't expire.
+func (l *Location) LeapExpires() (int64, bool) {
+	n := len(l.Leap)
+	if n < 2 || l.Leap[n-1].Correction != l.Leap[n-2].Correction {
+		return 0, false
+	}
+	return l.Leap[n-1].When, true
+}
+
+// Simple I/O interface to binary blob of data,
+// or to a reader if r is set.
 type dataIO struct {
 	p     []byte
+	r     io.Reader
+	off   int // offset of p in the whole data, or bytes read from r
+	limit int // maximum offset, if not zero
 	error bool
+	// overLimit is set if reading failed because of the limit.
+	overLimit bool
 }
 
 func (d *dataIO) read(n int) []byte {
+	if d.limit > 0 && d.off+n > d.limit {
+		// Consume the data up to the limit, so the error
+		// is reported where the data ends.
+		d.read(d.limit - d.off)
+		d.error = true
+		d.overLimit = true
+		return nil
+	}
+	if d.r != nil {
+		// Let the buffer grow as data arrives, so a bogus size
+		// doesn't allocate more than what is available.
+		var b bytes.Buffer
+		m, err := io.CopyN(&b, d.r, int64(n))
+		d.off += int(m)
+		d.p = b.Bytes()
+		if err != nil {
+			d.error = true
+			return nil
+		}
+		return d.p
+	}
 	if len(d.p) < n {
+		d.off += len(d.p)
 		d.p = nil
 		d.error = true
 		return nil
 	}
 	p := d.p[0:n]
 	d.p = d.p[n:]
+	d.off += n
 	return p
 }
 
+// skip skips n bytes without keeping them.
+func (d *dataIO) skip(n int) bool {
+	if d.r != nil && (d.limit == 0 || d.off+n <= d.limit) {
+		m, err := io.CopyN(ioutil.Discard, d.r, int64(n))
+		d.off += int(m)
+		if err != nil {
+			d.error = true
+			return false
+		}
+		return true
+	}
+	return d.read(n) != nil
+}
+
+// line reads up to the next newline, which is consumed but not returned.
+func (d *dataIO) line() ([]byte, bool) {
+	if d.r == nil {
+		i := bytes.IndexByte(d.p, '\n')
+		if i < 0 {
+			d.read(len(d.p) + 1)
+			return nil, false
+		}
+		p := d.read(i)
+		d.read(1)
+		return p, !d.error
+	}
+	var line []byte
+	for {
+		b, ok := d.byte()
+		if !ok {
+			return nil, false
+		}
+		if b == '\n' {
+			return line, true
+		}
+		line = append(line, b)
+	}
+}
+
+// sub returns the next n bytes as a separate dataIO.
+// If there are less than n bytes, it has the rest of the data.
+func (d *dataIO) sub(n int) dataIO {
+	s := dataIO{p: d.p, off: d.off}
+	if d.r != nil {
+		d.read(n)
+		s.p = d.p
+		return s
+	}
+	if d.read(n) != nil {
+		s.p = s.p[:n]
+	}
+	return s
+}
+
 func (d *dataIO) big4() (n uint32, ok bool) {
 	p := d.read(4)
 	if len(p) < 4 {
@@ -75,6 +220,29 @@ func (d *dataIO) byte() (n byte, ok bool) {
 	return p[0], true
 }
 
+// validLeap reports whether leap second record i is valid for the
+// given version. The records must be in order, and each must change the
+// correction by one, starting from zero.
+// Version 4 allows the table to be truncated at the start, so the first
+// correction can be anything, and to end with an expiration record that
+// repeats the last correction.
+func validLeap(leap []LeapSecond, i, version int) bool {
+	prev := int64(0)
+	if i > 0 {
+		if leap[i].When <= leap[i-1].When {
+			return false
+		}
+		prev = leap[i-1].Correction
+	} else if version >= 4 {
+		return true
+	}
+	d := leap[i].Correction - prev
+	if d == 0 && i > 0 && i == len(leap)-1 && version >= 4 {
+		return true
+	}
+	return d == 1 || d == -1
+}
+
 // Make a string by stopping at the first NUL
 func byteString(p []byte) string {
 	for i := 0; i < len(p); i++ {
@@ -85,25 +253,94 @@ func byteString(p []byte) string {
 	return string(p)
 }
 
-var errBadData = errors.New("malformed time zone information")
+// ErrBadData is the error a *ParseError wraps, so malformed time zone
+// information can be recognized with errors.Is.
+var ErrBadData = errors.New("malformed time zone information")
+
+// ParseError describes a problem found while parsing time zone information.
+// It matches the errors returned for malformed data by errors.Is.
+type ParseError struct {
+	Offset  int64  // byte offset of the problem in the data
+	Section string // part of the data: header, counts, transition times, zone types, abbreviations, leap seconds, indicators or footer
+	Value   string // the value found, if any
+	Reason  string // what is wrong
+}
+
+func (e *ParseError) Error() string {
+	s := fmt.Sprintf("%s: %s at offset %d: %s", ErrBadData, e.Section, e.Offset, e.Reason)
+	if e.Value != "" {
+		s += " (found " + e.Value + ")"
+	}
+	return s
+}
+
+// Unwrap returns the error for malformed time zone information.
+func (e *ParseError) Unwrap() error {
+	return ErrBadData
+}
+
+func badData(off int, section, value, reason string) error {
+	return &ParseError{Offset: int64(off), Section: section, Value: value, Reason: reason}
+}
+
+// ParseOptions limits the resources used to parse untrusted data.
+// A zero field means no limit.
+type ParseOptions struct {
+	MaxSize        int // size of the TZif data in bytes
+	MaxTransitions int // number of transitions
+	MaxZones       int // number of zone types
+	MaxAbbrevBytes int // size of the abbreviation table in bytes
+	MaxLeapSeconds int // number of leap second records
+}
 
 // ParseLocation returns a Location with the given name
 // initialized from the IANA Time Zone database-formatted data.
 // The data should be in the format of a standard IANA time zone file
 // (for example, the content of /etc/localtime on Unix systems).
+// Malformed data results in a *ParseError.
 func ParseLocation(name string, data []byte) (*Location, error) {
-	d := dataIO{data, false}
+	return ParseLocationWithOptions(name, data, ParseOptions{})
+}
+
+// ParseLocationWithOptions is like ParseLocation, but rejects data
+// exceeding the limits in opts before allocating memory for it.
+func ParseLocationWithOptions(name string, data []byte, opts ParseOptions) (*Location, error) {
+	d := dataIO{p: data, limit: opts.MaxSize}
+	return parseLocation(name, &d, opts)
+}
+
+// ReadLocation is like ParseLocationWithOptions, but reads the data from r.
+// It stops at the end of the TZif data and returns the number of bytes it
+// read, so data concatenated in a single stream can be read one by one.
+func ReadLocation(name string, r io.Reader, opts ParseOptions) (*Location, int64, error) {
+	d := dataIO{r: r, limit: opts.MaxSize}
+	l, err := parseLocation(name, &d, opts)
+	return l, int64(d.off), err
+}
+
+func parseLocation(name string, d *dataIO, opts ParseOptions) (*Location, error) {
+	l, err := parseData(name, d, opts)
+	if err != nil && d.overLimit {
+		var section string
+		if perr, ok := err.(*ParseError); ok {
+			section = perr.Section
+		}
+		return nil, badData(d.limit, section, "", "data size exceeds limit")
+	}
+	return l, err
+}
 
+func parseData(name string, d *dataIO, opts ParseOptions) (*Location, error) {
 	// 4-byte magic "TZif"
 	if magic := d.read(4); string(magic) != "TZif" {
-		return nil, errBadData
+		return nil, badData(0, "header", fmt.Sprintf("%q", magic), "missing TZif magic")
 	}
 
 	// 1-byte version, then 15 bytes of padding
 	var version int
 	var p []byte
 	if p = d.read(16); len(p) != 16 {
-		return nil, errBadData
+		return nil, badData(4, "header", "", "unexpected end of data")
 	} else {
 		switch p[0] {
 		case 0:
@@ -112,8 +349,10 @@ func ParseLocation(name string, data []byte) (*Location, error) {
 			version = 2
 		case '3':
 			version = 3
+		case '4':
+			version = 4
 		default:
-			return nil, errBadData
+			return nil, badData(4, "header", fmt.Sprintf("%q", p[0]), "unsupported version")
 		}
 	}
 
@@ -133,18 +372,48 @@ func ParseLocation(name string, data []byte) (*Location, error) {
 		NChar
 	)
 	var n [6]int
-	for i := 0; i < 6; i++ {
-		nn, ok := d.big4()
-		if !ok {
-			return nil, errBadData
+	readCounts := func() error {
+		start := d.off
+		for i := 0; i < 6; i++ {
+			off := d.off
+			nn, ok := d.big4()
+			if !ok {
+				return badData(off, "counts", "", "unexpected end of data")
+			}
+			if uint32(int(nn)) != nn {
+				return badData(off, "counts", strconv.FormatUint(uint64(nn), 10), "count too large")
+			}
+			n[i] = int(nn)
 		}
-		if uint32(int(nn)) != nn {
-			return nil, errBadData
+
+		// The counts must be consistent,
+		// and within the limits before we allocate anything.
+		checks := []struct {
+			i      int
+			bad    bool
+			reason string
+		}{
+			{NZone, n[NZone] == 0, "no zone types"},
+			{NChar, n[NChar] == 0, "no abbreviations"},
+			{NUTCLocal, n[NUTCLocal] != 0 && n[NUTCLocal] != n[NZone], "UT/local indicator count differs from zone type count"},
+			{NStdWall, n[NStdWall] != 0 && n[NStdWall] != n[NZone], "standard/wall indicator count differs from zone type count"},
+			{NTime, opts.MaxTransitions > 0 && n[NTime] > opts.MaxTransitions, "transition count exceeds limit"},
+			{NZone, opts.MaxZones > 0 && n[NZone] > opts.MaxZones, "zone type count exceeds limit"},
+			{NChar, opts.MaxAbbrevBytes > 0 && n[NChar] > opts.MaxAbbrevBytes, "abbreviation size exceeds limit"},
+			{NLeap, opts.MaxLeapSeconds > 0 && n[NLeap] > opts.MaxLeapSeconds, "leap second count exceeds limit"},
 		}
-		n[i] = int(nn)
+		for _, c := range checks {
+			if c.bad {
+				return badData(start+4*c.i, "counts", strconv.Itoa(n[c.i]), c.reason)
+			}
+		}
+		return nil
+	}
+	if err := readCounts(); err != nil {
+		return nil, err
 	}
 
-	// If we have version 2 or 3, then the data is first written out
+	// If we have version 2 or later, then the data is first written out
 	// in a 32-bit format, then written out again in a 64-bit format.
 	// Skip the 32-bit format and read the 64-bit one, as it can
 	// describe a broader range of dates.
@@ -159,22 +428,34 @@ func ParseLocation(name string, data []byte) (*Location, error) {
 			n[NLeap]*8 +
 			n[NStdWall] +
 			n[NUTCLocal]
-		// Skip the version 2 header that we just read.
-		skip += 4 + 16
-		d.read(skip)
+		if off := d.off; !d.skip(skip) {
+			return nil, badData(off, "transition times", "", "unexpected end of 32-bit data")
+		}
+
+		// The version 2 header repeats the magic and the version.
+		off := d.off
+		if p := d.read(4 + 16); p == nil {
+			return nil, badData(off, "header", "", "unexpected end of data")
+		} else if string(p[:4]) != "TZif" {
+			return nil, badData(off, "header", fmt.Sprintf("%q", p[:4]), "missing TZif magic in second header")
+		} else if p[4] != '0'+byte(version) {
+			return nil, badData(off+4, "header", fmt.Sprintf("%q", p[4]), "version differs from first header")
+		}
 
 		is64 = true
 
 		// Read the counts again, they can differ.
-		for i := 0; i < 6; i++ {
-			nn, ok := d.big4()
-			if !ok {
-				return nil, errBadData
-			}
-			if uint32(int(nn)) != nn {
-				return nil, errBadData
+		// The 32-bit data is a subset of the 64-bit data, so it can't
+		// have more transitions or leap seconds.
+		n32 := n
+		off = d.off
+		if err := readCounts(); err != nil {
+			return nil, err
+		}
+		for _, i := range []int{NTime, NLeap} {
+			if n[i] < n32[i] {
+				return nil, badData(off+4*i, "counts", strconv.Itoa(n[i]), "count less than in the 32-bit header")
 			}
-			n[i] = int(nn)
 		}
 	}
 
@@ -184,32 +465,72 @@ func ParseLocation(name string, data []byte) (*Location, error) {
 	}
 
 	// Transition times.
-	txtimes := dataIO{d.read(n[NTime] * size), false}
+	txtimes := d.sub(n[NTime] * size)
 
 	// Time zone indices for transition times.
-	txzones := d.read(n[NTime])
+	txzones := d.sub(n[NTime])
 
 	// Zone info structures
-	zonedata := dataIO{d.read(n[NZone] * 6), false}
+	zonedata := d.sub(n[NZone] * 6)
 
 	// Time zone abbreviations.
-	abbrev := d.read(n[NChar])
+	abbrevdata := d.sub(n[NChar])
+	abbrev := abbrevdata.p
 
 	// Leap-second time pairs
-	d.read(n[NLeap] * (size + 4))
+	leapdata := d.sub(n[NLeap] * (size + 4))
 
 	// Whether tx times associated with local time types
 	// are specified as standard time or wall time.
-	/* isstd := */
-	_ = d.read(n[NStdWall])
+	isstddata := d.sub(n[NStdWall])
+	isstd := isstddata.p
 
 	// Whether tx times associated with local time types
 	// are specified as UTC or local time.
-	/* isutc := */
-	_ = d.read(n[NUTCLocal])
+	isutcdata := d.sub(n[NUTCLocal])
+	isutc := isutcdata.p
 
 	if d.error { // ran out of data
-		return nil, errBadData
+		for _, s := range []struct {
+			d       dataIO
+			n       int
+			section string
+		}{
+			{txtimes, n[NTime] * size, "transition times"},
+			{txzones, n[NTime], "transition times"},
+			{zonedata, n[NZone] * 6, "zone types"},
+			{abbrevdata, n[NChar], "abbreviations"},
+			{leapdata, n[NLeap] * (size + 4), "leap seconds"},
+			{isstddata, n[NStdWall], "indicators"},
+			{isutcdata, n[NUTCLocal], "indicators"},
+		} {
+			if len(s.d.p) < s.n {
+				return nil, badData(s.d.off, s.section, "", "unexpected end of data")
+			}
+		}
+	}
+
+	// Version 2+ files end with a footer holding a POSIX TZ rule
+	// between two newlines, the rule itself may be empty.
+	var extend string
+	var rule *Rule
+	if is64 {
+		off := d.off
+		if p, ok := d.byte(); !ok || p != '\n' {
+			return nil, badData(off, "footer", "", "missing newline before footer")
+		}
+		off = d.off
+		p, ok := d.line()
+		if !ok {
+			return nil, badData(d.off, "footer", "", "missing newline after footer")
+		}
+		extend = string(p)
+		if extend != "" {
+			var err error
+			if rule, err = ParseRule(extend); err != nil {
+				return nil, badData(off, "footer", fmt.Sprintf("%q", extend), "malformed POSIX TZ rule")
+			}
+		}
 	}
 
 	// Now we can build up a useful data structure.
@@ -217,67 +538,106 @@ func ParseLocation(name string, data []byte) (*Location, error) {
 	//	utcoff[4] isdst[1] nameindex[1]
 	zone := make([]Zone, n[NZone])
 	for i := range zone {
+		off := zonedata.off
 		var ok bool
 		var n uint32
 		if n, ok = zonedata.big4(); !ok {
-			return nil, errBadData
-		}
-		if uint32(int(n)) != n {
-			return nil, errBadData
+			return nil, badData(off, "zone types", "", "unexpected end of data")
 		}
 		zone[i].Offset = int(int32(n))
 		var b byte
 		if b, ok = zonedata.byte(); !ok {
-			return nil, errBadData
+			return nil, badData(off+4, "zone types", "", "unexpected end of data")
 		}
 		zone[i].IsDST = b != 0
 		if b, ok = zonedata.byte(); !ok || int(b) >= len(abbrev) {
-			return nil, errBadData
+			return nil, badData(off+5, "zone types", strconv.Itoa(int(b)), "abbreviation index out of range")
 		}
 		zone[i].Name = byteString(abbrev[b:])
+		if i < len(isstd) {
+			zone[i].Isstd = isstd[i] != 0
+		}
+		if i < len(isutc) {
+			zone[i].Isutc = isutc[i] != 0
+		}
+	}
+
+	// Leap seconds: occurrence time followed by the correction.
+	var leap []LeapSecond
+	if n[NLeap] > 0 {
+		leap = make([]LeapSecond, n[NLeap])
+	}
+	for i := range leap {
+		off := leapdata.off
+		var n int64
+		if !is64 {
+			if n4, ok := leapdata.big4(); !ok {
+				return nil, badData(off, "leap seconds", "", "unexpected end of data")
+			} else {
+				n = int64(int32(n4))
+			}
+		} else {
+			if n8, ok := leapdata.big8(); !ok {
+				return nil, badData(off, "leap seconds", "", "unexpected end of data")
+			} else {
+				n = int64(n8)
+			}
+		}
+		leap[i].When = n
+		c, ok := leapdata.big4()
+		if !ok {
+			return nil, badData(off+size, "leap seconds", "", "unexpected end of data")
+		}
+		leap[i].Correction = int64(int32(c))
+		if !validLeap(leap, i, version) {
+			return nil, badData(off, "leap seconds", fmt.Sprintf("%d %d", leap[i].When, leap[i].Correction), "leap second out of order or correction not changed by one")
+		}
 	}
 
 	// Now the transition time info.
 	tx := make([]ZoneTrans, n[NTime])
 	for i := range tx {
+		off := txtimes.off
 		var n int64
 		if !is64 {
 			if n4, ok := txtimes.big4(); !ok {
-				return nil, errBadData
+				return nil, badData(off, "transition times", "", "unexpected end of data")
 			} else {
 				n = int64(int32(n4))
 			}
 		} else {
 			if n8, ok := txtimes.big8(); !ok {
-				return nil, errBadData
+				return nil, badData(off, "transition times", "", "unexpected end of data")
 			} else {
 				n = int64(n8)
 			}
 		}
 		tx[i].When = n
-		if int(txzones[i]) >= len(zone) {
-			return nil, errBadData
+		if int(txzones.p[i]) >= len(zone) {
+			return nil, badData(txzones.off+i, "transition times", strconv.Itoa(int(txzones.p[i])), "zone type index out of range")
 		}
-		tx[i].Index = txzones[i]
-		// These are ignored.
-		/*
-			if i < len(isstd) {
-				tx[i].Isstd = isstd[i] != 0
-			}
-			if i < len(isutc) {
-				tx[i].Isutc = isutc[i] != 0
-			}
-		*/
+		tx[i].Index = txzones.p[i]
+		tx[i].Isstd = zone[tx[i].Index].Isstd
+		tx[i].Isutc = zone[tx[i].Index].Isutc
 	}
 
 	if len(tx) == 0 {
 		// Build fake transition to cover all time.
 		// This happens in fixed locations like "Etc/GMT0".
-		tx = append(tx, ZoneTrans{When: alpha, Index: 0})
+		tx = append(tx, ZoneTrans{When: alpha, Index: 0, Isstd: zone[0].Isstd, Isutc: zone[0].Isutc})
 	}
 
 	// Committed to succeed.
-	l := &Location{Zone: zone, Tx: tx, Name: name}
+	l := &Location{
+		Name:    name,
+		Zone:    zone,
+		Tx:      tx,
+		Version: version,
+		Extend:  extend,
+		Rule:    rule,
+		Leap:    leap,
+		abbrev:  string(abbrev),
+	}
 
 	return l, nil
 }
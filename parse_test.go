@@ -1,6 +1,13 @@
 package tz
 
-import "testing"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
 
 func TestParse(t *testing.T) {
 	cases := []struct {
@@ -60,3 +67,401 @@ func BenchmarkParseLocation(b *testing.B) {
 		ParseLocation(name, data)
 	}
 }
+
+func TestParse_Extend(t *testing.T) {
+	cases := []struct {
+		name   string
+		extend string
+	}{
+		{"Etc/GMT-1", "<+01>-1"},
+		{"America/New_York", "EST5EDT,M3.2.0,M11.1.0"},
+		{"Australia/Sydney", "AEST-10AEDT,M10.1.0,M4.1.0/3"},
+		{"Asia/Jerusalem", "IST-2IDT,M3.4.4/26,M10.5.0"},
+		{"America/Godthab", "<-03>3<-02>,M3.5.0/-2,M10.5.0/-1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			locData, ok := TZData(c.name)
+			if !ok {
+				t.Fatalf("error loading timezone data")
+			}
+			l, err := ParseLocation(c.name, locData)
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			if l.Extend != c.extend {
+				t.Fatalf("got extend %q, want %q", l.Extend, c.extend)
+			}
+			if l.Rule == nil {
+				t.Fatalf("rule not parsed")
+			}
+			if s := l.Rule.String(); s != c.extend {
+				t.Fatalf("got rule %q, want %q", s, c.extend)
+			}
+		})
+	}
+}
+
+func TestParse_Leap(t *testing.T) {
+	locData, err := ioutil.ReadFile("testdata/right/UTC")
+	if err != nil {
+		t.Fatalf("error reading timezone data: %s", err)
+	}
+	l, err := ParseLocation("right/UTC", locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+
+	if len(l.Leap) != 27 {
+		t.Fatalf("got %d leap seconds, want 27", len(l.Leap))
+	}
+	first := LeapSecond{When: 78796800, Correction: 1}
+	if l.Leap[0] != first {
+		t.Fatalf("got first leap second %+v, want %+v", l.Leap[0], first)
+	}
+	last := LeapSecond{When: 1483228826, Correction: 27}
+	if l.Leap[26] != last {
+		t.Fatalf("got last leap second %+v, want %+v", l.Leap[26], last)
+	}
+	if l.LeapTruncated() {
+		t.Fatalf("leap second table reported as truncated")
+	}
+	if _, ok := l.LeapExpires(); ok {
+		t.Fatalf("leap second table reported as expiring")
+	}
+}
+
+func TestLocation_LeapTruncatedExpires(t *testing.T) {
+	l := &Location{Leap: []LeapSecond{
+		{When: 1341100824, Correction: 25},
+		{When: 1435708825, Correction: 26},
+		{When: 1483228826, Correction: 27},
+		{When: 1719532827, Correction: 27},
+	}}
+	if !l.LeapTruncated() {
+		t.Fatalf("leap second table not reported as truncated")
+	}
+	expires, ok := l.LeapExpires()
+	if !ok {
+		t.Fatalf("leap second table not reported as expiring")
+	}
+	if expires != 1719532827 {
+		t.Fatalf("got expiration %d, want %d", expires, 1719532827)
+	}
+}
+
+func TestParse_Indicators(t *testing.T) {
+	name := "America/New_York"
+	locData, ok := TZData(name)
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+	l, err := ParseLocation(name, locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+
+	for _, zone := range l.Zone {
+		want := zone.Name == "EPT"
+		if zone.Isstd != want || zone.Isutc != want {
+			t.Errorf("zone %s: got isstd %t, isutc %t, want %t", zone.Name, zone.Isstd, zone.Isutc, want)
+		}
+	}
+	for _, tx := range l.Tx {
+		zone := l.Zone[tx.Index]
+		if tx.Isstd != zone.Isstd || tx.Isutc != zone.Isutc {
+			t.Errorf("transition at %d: got isstd %t, isutc %t, want %t, %t", tx.When, tx.Isstd, tx.Isutc, zone.Isstd, zone.Isutc)
+		}
+	}
+}
+
+func TestParse_Version4(t *testing.T) {
+	locData, err := ioutil.ReadFile("testdata/right/UTC")
+	if err != nil {
+		t.Fatalf("error reading timezone data: %s", err)
+	}
+	l, err := ParseLocation("right/UTC", locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+
+	// Truncate the table to the last three leap seconds,
+	// and add an expiration record.
+	l.Leap = append(l.Leap[len(l.Leap)-3:], LeapSecond{When: 1719532827, Correction: 27})
+	l.Version = 0
+	data, err := l.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error encoding location: %s", err)
+	}
+	if data[4] != '4' {
+		t.Fatalf("got version %q, want '4'", data[4])
+	}
+
+	v4, err := ParseLocation("right/UTC", data)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	if v4.Version != 4 {
+		t.Fatalf("got version %d, want 4", v4.Version)
+	}
+	if !v4.LeapTruncated() {
+		t.Fatalf("leap second table not reported as truncated")
+	}
+	if expires, ok := v4.LeapExpires(); !ok || expires != 1719532827 {
+		t.Fatalf("got expiration %d, %t, want %d", expires, ok, 1719532827)
+	}
+
+	// Earlier versions don't allow truncation or expiration.
+	v3 := append([]byte(nil), data...)
+	for i := 0; i+4 < len(v3); i++ {
+		if string(v3[i:i+5]) == "TZif4" {
+			v3[i+4] = '3'
+		}
+	}
+	if _, err := ParseLocation("right/UTC", v3); err == nil {
+		t.Fatalf("got no error for version 3 data with a truncated leap second table")
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	// Etc/GMT-1 has no transitions and a single zone type,
+	// the 64-bit zone type is at offset 98, the footer at 108.
+	name := "Etc/GMT-1"
+	locData, ok := TZData(name)
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+
+	cases := []struct {
+		name    string
+		modify  func(data []byte) []byte
+		offset  int64
+		section string
+		value   string
+	}{
+		{
+			name:    "magic",
+			modify:  func(data []byte) []byte { data[0] = 'X'; return data },
+			offset:  0,
+			section: "header",
+			value:   `"XZif"`,
+		},
+		{
+			name:    "version",
+			modify:  func(data []byte) []byte { data[4] = '5'; return data },
+			offset:  4,
+			section: "header",
+			value:   `'5'`,
+		},
+		{
+			name:    "second header version",
+			modify:  func(data []byte) []byte { data[58] = '3'; return data },
+			offset:  58,
+			section: "header",
+			value:   `'3'`,
+		},
+		{
+			name:    "truncated counts",
+			modify:  func(data []byte) []byte { return data[:30] },
+			offset:  28,
+			section: "counts",
+		},
+		{
+			name:    "truncated zone types",
+			modify:  func(data []byte) []byte { return data[:100] },
+			offset:  98,
+			section: "zone types",
+		},
+		{
+			name:    "abbreviation index",
+			modify:  func(data []byte) []byte { data[103] = 9; return data },
+			offset:  103,
+			section: "zone types",
+			value:   "9",
+		},
+		{
+			name:    "footer",
+			modify:  func(data []byte) []byte { return append(data[:109], "<+01>\n"...) },
+			offset:  109,
+			section: "footer",
+			value:   `"<+01>"`,
+		},
+		{
+			name:    "missing footer",
+			modify:  func(data []byte) []byte { return data[:108] },
+			offset:  108,
+			section: "footer",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			data := c.modify(append([]byte(nil), locData...))
+			_, err := ParseLocation(name, data)
+			if err == nil {
+				t.Fatalf("got no error")
+			}
+			if !errors.Is(err, ErrBadData) {
+				t.Fatalf("error %q does not match ErrBadData", err)
+			}
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("got error %T, want *ParseError", err)
+			}
+			if perr.Offset != c.offset || perr.Section != c.section || perr.Value != c.value {
+				t.Fatalf("got offset %d, section %q, value %q, want %d, %q, %q: %s", perr.Offset, perr.Section, perr.Value, c.offset, c.section, c.value, err)
+			}
+		})
+	}
+}
+
+func TestParseLocationWithOptions(t *testing.T) {
+	name := "US/Central"
+	locData, ok := TZData(name)
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+
+	cases := []struct {
+		name    string
+		opts    ParseOptions
+		wantErr bool
+	}{
+		{"no limits", ParseOptions{}, false},
+		{"within limits", ParseOptions{MaxSize: len(locData), MaxTransitions: 236, MaxZones: 7, MaxAbbrevBytes: 24, MaxLeapSeconds: 1}, false},
+		{"size", ParseOptions{MaxSize: len(locData) - 1}, true},
+		{"transitions", ParseOptions{MaxTransitions: 235}, true},
+		{"zones", ParseOptions{MaxZones: 6}, true},
+		{"abbreviations", ParseOptions{MaxAbbrevBytes: 23}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := ParseLocationWithOptions(name, locData, c.opts)
+			if err != nil && !c.wantErr {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			if err == nil && c.wantErr {
+				t.Fatalf("got no error")
+			}
+		})
+	}
+}
+
+func TestParseLocationWithOptions_HugeCounts(t *testing.T) {
+	// A header claiming a billion transitions, with no data.
+	data := []byte("TZif2\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+		"\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00" +
+		"\x40\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x04")
+
+	_, err := ParseLocationWithOptions("Huge", data, ParseOptions{MaxTransitions: 1000})
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("got error %v, want *ParseError", err)
+	}
+	if perr.Section != "counts" || perr.Offset != 32 {
+		t.Fatalf("got section %q, offset %d, want counts, 32: %s", perr.Section, perr.Offset, err)
+	}
+
+	if _, err := ParseLocation("Huge", data); !errors.Is(err, ErrBadData) {
+		t.Fatalf("got error %v, want ErrBadData", err)
+	}
+}
+
+func TestParse_InconsistentCounts(t *testing.T) {
+	name := "US/Central"
+	locData, ok := TZData(name)
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+
+	// US/Central has 7 zone types, claim 3 UT/local indicators.
+	data := append([]byte(nil), locData...)
+	data[23] = 3
+	_, err := ParseLocation(name, data)
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("got error %v, want *ParseError", err)
+	}
+	if perr.Section != "counts" || perr.Offset != 20 || perr.Value != "3" {
+		t.Fatalf("got section %q, offset %d, value %q, want counts, 20, 3: %s", perr.Section, perr.Offset, perr.Value, err)
+	}
+}
+
+func TestReadLocation(t *testing.T) {
+	names := []string{"America/New_York", "Etc/GMT-1", "Australia/Sydney", "US/Central"}
+
+	var stream []byte
+	var want []*Location
+	for i, name := range names {
+		locData, ok := TZData(name)
+		if !ok {
+			t.Fatalf("error loading timezone data")
+		}
+		l, err := ParseLocation(name, locData)
+		if err != nil {
+			t.Fatalf("error parsing location: %s", err)
+		}
+		if i == len(names)-1 {
+			// Version 1 data has no footer.
+			var buf bytes.Buffer
+			if err := WriteTZif(&buf, l, 1); err != nil {
+				t.Fatalf("error encoding location: %s", err)
+			}
+			locData = buf.Bytes()
+			if l, err = ParseLocation(name, locData); err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+		}
+		stream = append(stream, locData...)
+		want = append(want, l)
+	}
+
+	// MultiReader isn't an io.ByteReader, so nothing can be read ahead.
+	r := io.MultiReader(bytes.NewReader(stream))
+	var total int64
+	for i, name := range names {
+		l, n, err := ReadLocation(name, r, ParseOptions{})
+		if err != nil {
+			t.Fatalf("%s: error reading location: %s", name, err)
+		}
+		total += n
+		if !reflect.DeepEqual(l, want[i]) {
+			t.Fatalf("%s: got %+v, want %+v", name, l, want[i])
+		}
+	}
+	if total != int64(len(stream)) {
+		t.Fatalf("read %d bytes, want %d", total, len(stream))
+	}
+	if _, n, err := ReadLocation("EOF", r, ParseOptions{}); err == nil || n != 0 {
+		t.Fatalf("got %d bytes and error %v at the end of the stream", n, err)
+	}
+}
+
+func TestReadLocation_Limits(t *testing.T) {
+	name := "US/Central"
+	locData, ok := TZData(name)
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+
+	_, n, err := ReadLocation(name, bytes.NewReader(locData), ParseOptions{MaxSize: 1000})
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("got error %v, want *ParseError", err)
+	}
+	if perr.Offset != 1000 || n != 1000 {
+		t.Fatalf("got offset %d, %d bytes read, want 1000: %s", perr.Offset, n, err)
+	}
+
+	_, _, err = ReadLocation(name, bytes.NewReader(locData), ParseOptions{MaxTransitions: 100})
+	if !errors.As(err, &perr) || perr.Section != "counts" {
+		t.Fatalf("got error %v, want counts error", err)
+	}
+
+	_, _, err = ReadLocation(name, bytes.NewReader(locData[:2000]), ParseOptions{})
+	if !errors.Is(err, ErrBadData) {
+		t.Fatalf("got error %v, want ErrBadData", err)
+	}
+}
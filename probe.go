@@ -0,0 +1,57 @@
+package tz
+
+import "time"
+
+// probeStep is the interval, in seconds, at which FromTimeLocation
+// samples a time.Location. Transitions closer together than this,
+// with the same zone before and after them, are not found.
+// No zone in the time zone database has any.
+const probeStep = 12 * secondsPerHour
+
+// FromTimeLocation returns a location with the zones and transitions of loc
+// between from and to, in seconds since January 1, 1970 UTC.
+// The transitions are found by sampling loc and bisecting to the exact
+// second of each change of zone name, offset or daylight saving time.
+//
+// The location has no footer rule: instants before from resolve to the
+// zone in effect at from, and instants after to resolve to the zone
+// in effect at to.
+func FromTimeLocation(loc *time.Location, from, to int64) *Location {
+	l := &Location{Name: loc.String()}
+	prev := timeZone(loc, from)
+	l.Tx = append(l.Tx, ZoneTrans{When: alpha, Index: l.zoneIndex(prev)})
+
+	for sec := from; sec < to; {
+		next := sec + probeStep
+		if next > to || next < sec {
+			next = to
+		}
+		zone := timeZone(loc, next)
+		if zone == prev {
+			sec = next
+			continue
+		}
+		// The zone changes at some instant in (sec, next].
+		lo, hi := sec, next
+		for hi-lo > 1 {
+			m := lo + (hi-lo)/2
+			if timeZone(loc, m) == prev {
+				lo = m
+			} else {
+				hi = m
+			}
+		}
+		zone = timeZone(loc, hi)
+		l.Tx = append(l.Tx, ZoneTrans{When: hi, Index: l.zoneIndex(zone)})
+		prev = zone
+		sec = hi
+	}
+	return l
+}
+
+// timeZone returns the zone loc uses at sec.
+func timeZone(loc *time.Location, sec int64) Zone {
+	t := time.Unix(sec, 0).In(loc)
+	name, offset := t.Zone()
+	return Zone{Name: name, Offset: offset, IsDST: t.IsDST()}
+}
@@ -0,0 +1,77 @@
+package tz
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFromTimeLocation(t *testing.T) {
+	cases := []struct {
+		name string
+	}{
+		{"UTC"},
+		{"America/New_York"},
+		{"Australia/Lord_Howe"},
+		{"Europe/Dublin"},
+		{"Pacific/Apia"},
+		{"Asia/Kathmandu"},
+		{"Africa/Casablanca"},
+	}
+
+	from := time.Date(1900, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2040, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			stdlibLoc := mustLoadLocation(t, c.name)
+			want, err := locationByName(c.name)
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			l := FromTimeLocation(stdlibLoc, from, to)
+			if l.Name != c.name {
+				t.Fatalf("got name %s, want %s", l.Name, c.name)
+			}
+
+			// Every change of zone is a transition, at the same instant.
+			var wantTx []int64
+			for _, tx := range want.ExtendTo(2040).Tx {
+				if from < tx.When && tx.When <= to {
+					if prev, _, _ := want.Lookup(tx.When - 1); !sameZone(prev, want.Zone[tx.Index]) {
+						wantTx = append(wantTx, tx.When)
+					}
+				}
+			}
+			gotTx := l.Tx[1:]
+			if len(gotTx) != len(wantTx) {
+				t.Fatalf("got %d transitions, want %d", len(gotTx), len(wantTx))
+			}
+			for i := range gotTx {
+				if gotTx[i].When != wantTx[i] {
+					t.Fatalf("transition %d: got %d, want %d", i, gotTx[i].When, wantTx[i])
+				}
+			}
+
+			for sec := from; sec < to; sec += 86400 {
+				got, _, _ := l.Lookup(sec)
+				if want := timeZone(stdlibLoc, sec); got != want {
+					t.Fatalf("at %d: got %v, want %v", sec, got, want)
+				}
+			}
+			if got, want := l.Zone[l.Tx[0].Index], timeZone(stdlibLoc, from); got != want {
+				t.Fatalf("got first zone %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFromTimeLocation_Fixed(t *testing.T) {
+	loc := time.FixedZone("XYZ", 3*3600+1800)
+	l := FromTimeLocation(loc, 0, 1e9)
+	if len(l.Tx) != 1 || len(l.Zone) != 1 {
+		t.Fatalf("got %d transitions and %d zones, want 1 and 1", len(l.Tx), len(l.Zone))
+	}
+	if zone, start, end := l.Lookup(5e8); zone.Name != "XYZ" || zone.Offset != 12600 || start != alpha || end != omega {
+		t.Fatalf("got %v %d %d", zone, start, end)
+	}
+}
@@ -0,0 +1,200 @@
+package tz
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Policy selects the instant Resolve returns for a local time that
+// doesn't exist, because a transition skipped it, or that is ambiguous,
+// because a transition repeated it.
+type Policy int
+
+const (
+	// PolicyReject returns a *NonexistentTimeError or an *AmbiguousTimeError.
+	PolicyReject Policy = iota
+	// PolicyEarlier uses the earliest instant of an ambiguous time.
+	// A nonexistent time is moved back by the length of the gap.
+	PolicyEarlier
+	// PolicyLater uses the latest instant of an ambiguous time.
+	// A nonexistent time is moved forward by the length of the gap.
+	PolicyLater
+	// PolicyShiftForward moves a nonexistent time forward by the length
+	// of the gap, and uses the earliest instant of an ambiguous time.
+	PolicyShiftForward
+)
+
+// A NonexistentTimeError records a local time skipped by a transition.
+type NonexistentTimeError struct {
+	Location string
+	// Local is the local time, with location UTC.
+	Local time.Time
+	// Transition is the instant of the transition that skipped
+	// the local time, Before and After are the zones it is between.
+	Transition    int64
+	Before, After Zone
+}
+
+func (e *NonexistentTimeError) Error() string {
+	return fmt.Sprintf("local time %s does not exist in %s", e.Local.Format("2006-01-02 15:04:05"), e.Location)
+}
+
+// An AmbiguousTimeError records a local time that occurs more than once.
+type AmbiguousTimeError struct {
+	Location string
+	// Local is the local time, with location UTC.
+	Local time.Time
+	// Instants are the instants the local time occurs at, in order.
+	Instants []int64
+}
+
+func (e *AmbiguousTimeError) Error() string {
+	return fmt.Sprintf("local time %s is ambiguous in %s", e.Local.Format("2006-01-02 15:04:05"), e.Location)
+}
+
+// Candidates returns every instant, in seconds since January 1, 1970 UTC,
+// at which the local time in the location is the given one, in order.
+// The arguments are normalized like the arguments of time.Date.
+//
+// There is one instant for most local times, none for a local time
+// skipped by a transition, and more than one for a local time
+// repeated by a transition.
+func (l *Location) Candidates(year int, month time.Month, day, hour, min, sec int) []int64 {
+	return l.candidates(wallSeconds(year, month, day, hour, min, sec))
+}
+
+// Resolve returns the instant, in seconds since January 1, 1970 UTC,
+// at which the local time in the location is the given one.
+// The arguments are normalized like the arguments of time.Date.
+// The policy selects the instant to return if the local time
+// doesn't exist or is ambiguous.
+func (l *Location) Resolve(year int, month time.Month, day, hour, min, sec int, policy Policy) (int64, error) {
+	wall := wallSeconds(year, month, day, hour, min, sec)
+	instants := l.candidates(wall)
+	switch {
+	case len(instants) == 1:
+		return instants[0], nil
+	case len(instants) > 1:
+		switch policy {
+		case PolicyEarlier, PolicyShiftForward:
+			return instants[0], nil
+		case PolicyLater:
+			return instants[len(instants)-1], nil
+		}
+		return 0, &AmbiguousTimeError{
+			Location: l.Name,
+			Local:    time.Unix(wall, 0).UTC(),
+			Instants: instants,
+		}
+	}
+
+	tx, before, after, ok := l.gap(wall)
+	if !ok {
+		return 0, errors.New("no transition skips local time")
+	}
+	switch policy {
+	case PolicyEarlier:
+		return wall - int64(after.Offset), nil
+	case PolicyLater, PolicyShiftForward:
+		return wall - int64(before.Offset), nil
+	}
+	return 0, &NonexistentTimeError{
+		Location:   l.Name,
+		Local:      time.Unix(wall, 0).UTC(),
+		Transition: tx,
+		Before:     before,
+		After:      after,
+	}
+}
+
+// Candidates is like Location.Candidates, for the location with the given name.
+func Candidates(name string, year int, month time.Month, day, hour, min, sec int) ([]int64, error) {
+	l, err := locationByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return l.Candidates(year, month, day, hour, min, sec), nil
+}
+
+// Resolve is like Location.Resolve, for the location with the given name.
+func Resolve(name string, year int, month time.Month, day, hour, min, sec int, policy Policy) (int64, error) {
+	l, err := locationByName(name)
+	if err != nil {
+		return 0, err
+	}
+	return l.Resolve(year, month, day, hour, min, sec, policy)
+}
+
+// locationByName returns the parsed embedded location with the given name.
+// The empty name and "UTC" are UTC.
+func locationByName(name string) (*Location, error) {
+	if name == "" || name == "UTC" {
+		return &Location{Name: "UTC"}, nil
+	}
+	data, ok := TZData(name)
+	if !ok {
+		return nil, unknownLocation(name)
+	}
+	return ParseLocation(name, data)
+}
+
+// wallSeconds returns the local time as seconds since January 1, 1970
+// on the local clock.
+func wallSeconds(year int, month time.Month, day, hour, min, sec int) int64 {
+	return time.Date(year, month, day, hour, min, sec, 0, time.UTC).Unix()
+}
+
+// candidates returns the instants at which the local clock shows wall.
+func (l *Location) candidates(wall int64) []int64 {
+	var instants []int64
+	for _, off := range l.offsets() {
+		sec := wall - int64(off)
+		if zone, _, _ := l.Lookup(sec); zone.Offset == off {
+			instants = append(instants, sec)
+		}
+	}
+	sort.Slice(instants, func(i, j int) bool { return instants[i] < instants[j] })
+	return instants
+}
+
+// gap returns the transition that skips the local time wall,
+// and the zones before and after it.
+func (l *Location) gap(wall int64) (tx int64, before, after Zone, ok bool) {
+	for _, off := range l.offsets() {
+		after, tx, _ = l.Lookup(wall - int64(off))
+		if tx == alpha {
+			continue
+		}
+		before, _, _ = l.Lookup(tx - 1)
+		if tx+int64(before.Offset) <= wall && wall < tx+int64(after.Offset) {
+			return tx, before, after, true
+		}
+	}
+	return 0, Zone{}, Zone{}, false
+}
+
+// offsets returns the distinct offsets of the location's zones,
+// including the zones of the footer rule.
+func (l *Location) offsets() []int {
+	zones := append([]Zone(nil), l.Zone...)
+	if len(zones) == 0 {
+		zones = append(zones, Zone{Name: "UTC"})
+	}
+	if l.Rule != nil {
+		zones = append(zones, l.Rule.Std)
+		if l.Rule.HasDST {
+			zones = append(zones, l.Rule.DST)
+		}
+	}
+	var offsets []int
+	seen := make(map[int]bool)
+	for _, zone := range zones {
+		if !seen[zone.Offset] {
+			seen[zone.Offset] = true
+			offsets = append(offsets, zone.Offset)
+		}
+	}
+	return offsets
+}
@@ -0,0 +1,164 @@
+package tz
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	name := "America/New_York"
+	est := int64(-5 * 3600)
+	edt := int64(-4 * 3600)
+	gapWall := time.Date(2019, time.March, 10, 2, 30, 0, 0, time.UTC).Unix()
+	overlapWall := time.Date(2019, time.November, 3, 1, 30, 0, 0, time.UTC).Unix()
+
+	cases := []struct {
+		hour, day int
+		month     time.Month
+		policy    Policy
+		want      int64
+		wantErr   interface{}
+	}{
+		{2, 10, time.March, PolicyReject, 0, &NonexistentTimeError{}},
+		{2, 10, time.March, PolicyEarlier, gapWall - edt, nil},
+		{2, 10, time.March, PolicyLater, gapWall - est, nil},
+		{2, 10, time.March, PolicyShiftForward, gapWall - est, nil},
+		{1, 3, time.November, PolicyReject, 0, &AmbiguousTimeError{}},
+		{1, 3, time.November, PolicyEarlier, overlapWall - edt, nil},
+		{1, 3, time.November, PolicyLater, overlapWall - est, nil},
+		{1, 3, time.November, PolicyShiftForward, overlapWall - edt, nil},
+	}
+
+	for _, c := range cases {
+		got, err := Resolve(name, 2019, c.month, c.day, c.hour, 30, 0, c.policy)
+		switch want := c.wantErr.(type) {
+		case *NonexistentTimeError:
+			if !errors.As(err, &want) {
+				t.Fatalf("%s %d policy %d: got error %v, want nonexistent time", c.month, c.day, c.policy, err)
+			}
+			if want.Transition != time.Date(2019, time.March, 10, 7, 0, 0, 0, time.UTC).Unix() || want.Before.Name != "EST" || want.After.Name != "EDT" {
+				t.Fatalf("got %+v", want)
+			}
+			if want.Error() != "local time 2019-03-10 02:30:00 does not exist in America/New_York" {
+				t.Fatalf("got error message %s", want)
+			}
+		case *AmbiguousTimeError:
+			if !errors.As(err, &want) {
+				t.Fatalf("%s %d policy %d: got error %v, want ambiguous time", c.month, c.day, c.policy, err)
+			}
+			if len(want.Instants) != 2 || want.Instants[0] != overlapWall-edt || want.Instants[1] != overlapWall-est {
+				t.Fatalf("got instants %v", want.Instants)
+			}
+		default:
+			if err != nil {
+				t.Fatalf("%s %d policy %d: got error %s", c.month, c.day, c.policy, err)
+			}
+			if got != c.want {
+				t.Fatalf("%s %d policy %d: got %d, want %d", c.month, c.day, c.policy, got, c.want)
+			}
+		}
+	}
+
+	if _, err := Resolve("not-a-real-location", 2019, time.March, 10, 2, 30, 0, PolicyReject); err == nil {
+		t.Fatalf("got no error for unknown location")
+	}
+}
+
+func TestCandidates_ByName(t *testing.T) {
+	name := "America/New_York"
+	est := int64(-5 * 3600)
+	edt := int64(-4 * 3600)
+
+	// Ambiguous: 01:30 happens twice when daylight saving time ends.
+	instants, err := Candidates(name, 2019, time.November, 3, 1, 30, 0)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	wall := time.Date(2019, time.November, 3, 1, 30, 0, 0, time.UTC).Unix()
+	if len(instants) != 2 || instants[0] != wall-edt || instants[1] != wall-est {
+		t.Fatalf("got instants %v, want %d and %d", instants, wall-edt, wall-est)
+	}
+
+	// Nonexistent: 02:30 is skipped when daylight saving time starts.
+	instants, err = Candidates(name, 2019, time.March, 10, 2, 30, 0)
+	if err != nil {
+		t.Fatalf("got error %s", err)
+	}
+	if len(instants) != 0 {
+		t.Fatalf("got instants %v, want none", instants)
+	}
+
+	if _, err := Candidates("not-a-real-location", 2019, time.March, 10, 2, 30, 0); err == nil {
+		t.Fatalf("got no error for unknown location")
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	cases := []struct {
+		name string
+	}{
+		{"UTC"},
+		{"America/New_York"},
+		{"Australia/Lord_Howe"},
+		{"Europe/London"},
+		{"Pacific/Apia"},
+		{"Asia/Kolkata"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, err := locationByName(c.name)
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			stdlibLoc := mustLoadLocation(t, c.name)
+
+			check := func(sec int64) {
+				wall := time.Unix(sec, 0).In(stdlibLoc)
+				instants := l.Candidates(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second())
+				found := false
+				for i, instant := range instants {
+					found = found || instant == sec
+					if i > 0 && instants[i-1] >= instant {
+						t.Fatalf("at %d: instants out of order: %v", sec, instants)
+					}
+					if got := time.Unix(instant, 0).In(stdlibLoc); !sameWall(got, wall) {
+						t.Fatalf("at %d: instant %d is %s, want %s", sec, instant, got, wall)
+					}
+				}
+				if !found {
+					t.Fatalf("at %d: got instants %v for %s", sec, instants, wall)
+				}
+			}
+			for sec := int64(-2e9); sec < 3e9; sec += 86400 {
+				check(sec)
+			}
+			for _, tx := range l.ExtendTo(2060).Tx {
+				if tx.When == alpha {
+					continue
+				}
+				for sec := tx.When - 2*86400; sec < tx.When+2*86400; sec += 900 {
+					check(sec)
+				}
+
+				// A gap.
+				before, _, _ := l.Lookup(tx.When - 1)
+				after := l.Zone[tx.Index]
+				if after.Offset > before.Offset {
+					wall := time.Unix(tx.When+int64(before.Offset), 0).UTC()
+					_, err := l.Resolve(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), PolicyReject)
+					if e, ok := err.(*NonexistentTimeError); !ok || e.Transition != tx.When {
+						t.Fatalf("at %d: got error %v, want nonexistent time", tx.When, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func sameWall(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd && a.Hour() == b.Hour() && a.Minute() == b.Minute() && a.Second() == b.Second()
+}
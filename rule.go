@@ -0,0 +1,463 @@
+package tz
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RuleKind is the form of a RuleDate.
+type RuleKind int
+
+const (
+	// RuleJulian is the Jn form: Julian day n (1 <= n <= 365).
+	// Leap days are not counted, so February 29 can't be referenced.
+	RuleJulian RuleKind = iota
+	// RuleDOY is the n form: zero-based day of year n (0 <= n <= 365).
+	// Leap days are counted.
+	RuleDOY
+	// RuleMonthWeekDay is the Mm.w.d form: day d (0 <= d <= 6, Sunday is 0)
+	// of week w (1 <= w <= 5, 5 is the last week) of month m (1 <= m <= 12).
+	RuleMonthWeekDay
+)
+
+// RuleDate is the date and time when a Rule switches
+// to or from daylight saving time.
+type RuleDate struct {
+	Kind  RuleKind
+	Day   int // Julian day, day of year or day of week, depending on Kind
+	Week  int // week of the month, only for RuleMonthWeekDay
+	Month int // month, only for RuleMonthWeekDay
+	Time  int // local time of day in seconds, can be negative or exceed 24 hours
+}
+
+// Rule is a POSIX TZ rule, such as "EST5EDT,M3.2.0,M11.1.0".
+// Version 2 and later TZif files end with a footer containing such a rule,
+// which describes the zone after the last transition.
+type Rule struct {
+	Std    Zone     // standard time
+	DST    Zone     // daylight saving time, only if HasDST
+	HasDST bool     // does the rule use daylight saving time?
+	Start  RuleDate // when daylight saving time starts, in standard local time
+	End    RuleDate // when daylight saving time ends, in daylight local time
+}
+
+var errBadRule = errors.New("malformed time zone rule")
+
+// ParseRule parses a POSIX TZ rule, as found in the TZ environment variable
+// and in the footer of TZif files.
+// The extensions from RFC 8536 are supported: angle-bracket quoted
+// abbreviations and transition times from -167 to 167 hours.
+func ParseRule(s string) (*Rule, error) {
+	var (
+		r  Rule
+		ok bool
+	)
+
+	r.Std.Name, s, ok = ruleName(s)
+	if ok {
+		r.Std.Offset, s, ok = ruleOffset(s)
+	}
+	if !ok {
+		return nil, errBadRule
+	}
+
+	// The offsets in the rule are added to local time to get UTC,
+	// but our offsets are added to UTC to get local time,
+	// so we negate the number we see here.
+	r.Std.Offset = -r.Std.Offset
+
+	if len(s) == 0 || s[0] == ',' {
+		// No daylight saving time.
+		return &r, nil
+	}
+
+	r.HasDST = true
+	r.DST.IsDST = true
+	r.DST.Name, s, ok = ruleName(s)
+	if ok {
+		if len(s) == 0 || s[0] == ',' || s[0] == ';' {
+			r.DST.Offset = r.Std.Offset + secondsPerHour
+		} else {
+			r.DST.Offset, s, ok = ruleOffset(s)
+			r.DST.Offset = -r.DST.Offset // as with Std.Offset, above
+		}
+	}
+	if !ok {
+		return nil, errBadRule
+	}
+
+	if len(s) == 0 {
+		// Default DST rules per tzcode.
+		s = ",M3.2.0,M11.1.0"
+	}
+	// The TZ definition does not mention ';' here but tzcode accepts it.
+	if s[0] != ',' && s[0] != ';' {
+		return nil, errBadRule
+	}
+	s = s[1:]
+
+	r.Start, s, ok = ruleDate(s)
+	if !ok || len(s) == 0 || s[0] != ',' {
+		return nil, errBadRule
+	}
+	s = s[1:]
+	r.End, s, ok = ruleDate(s)
+	if !ok || len(s) > 0 {
+		return nil, errBadRule
+	}
+
+	return &r, nil
+}
+
+// ruleName returns the abbreviation at the start of the rule string s,
+// and the remainder of s, and reports whether the parsing is OK.
+func ruleName(s string) (string, string, bool) {
+	if len(s) == 0 {
+		return "", "", false
+	}
+	if s[0] != '<' {
+		for i, r := range s {
+			switch r {
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', ',', '-', '+':
+				if i < 3 {
+					return "", "", false
+				}
+				return s[:i], s[i:], true
+			}
+		}
+		if len(s) < 3 {
+			return "", "", false
+		}
+		return s, "", true
+	}
+	for i, r := range s {
+		if r == '>' {
+			return s[1:i], s[i+1:], true
+		}
+	}
+	return "", "", false
+}
+
+// ruleOffset returns the offset at the start of the rule string s,
+// and the remainder of s, and reports whether the parsing is OK.
+// The offset is returned as a number of seconds.
+func ruleOffset(s string) (offset int, rest string, ok bool) {
+	if len(s) == 0 {
+		return 0, "", false
+	}
+	neg := false
+	if s[0] == '+' {
+		s = s[1:]
+	} else if s[0] == '-' {
+		s = s[1:]
+		neg = true
+	}
+
+	// The tzdata code permits values up to 24 * 7 here,
+	// although POSIX does not.
+	var hours int
+	hours, s, ok = ruleNum(s, 0, 24*7)
+	if !ok {
+		return 0, "", false
+	}
+	off := hours * secondsPerHour
+	if len(s) > 0 && s[0] == ':' {
+		var mins int
+		mins, s, ok = ruleNum(s[1:], 0, 59)
+		if !ok {
+			return 0, "", false
+		}
+		off += mins * secondsPerMinute
+		if len(s) > 0 && s[0] == ':' {
+			var secs int
+			secs, s, ok = ruleNum(s[1:], 0, 59)
+			if !ok {
+				return 0, "", false
+			}
+			off += secs
+		}
+	}
+
+	if neg {
+		off = -off
+	}
+	return off, s, true
+}
+
+// ruleDate parses a date and optional time from a rule string.
+// It returns the date, and the remainder of the string, and reports success.
+func ruleDate(s string) (RuleDate, string, bool) {
+	var (
+		r  RuleDate
+		ok bool
+	)
+	if len(s) == 0 {
+		return RuleDate{}, "", false
+	}
+	if s[0] == 'J' {
+		r.Kind = RuleJulian
+		r.Day, s, ok = ruleNum(s[1:], 1, 365)
+		if !ok {
+			return RuleDate{}, "", false
+		}
+	} else if s[0] == 'M' {
+		r.Kind = RuleMonthWeekDay
+		r.Month, s, ok = ruleNum(s[1:], 1, 12)
+		if !ok || len(s) == 0 || s[0] != '.' {
+			return RuleDate{}, "", false
+		}
+		r.Week, s, ok = ruleNum(s[1:], 1, 5)
+		if !ok || len(s) == 0 || s[0] != '.' {
+			return RuleDate{}, "", false
+		}
+		r.Day, s, ok = ruleNum(s[1:], 0, 6)
+		if !ok {
+			return RuleDate{}, "", false
+		}
+	} else {
+		r.Kind = RuleDOY
+		r.Day, s, ok = ruleNum(s, 0, 365)
+		if !ok {
+			return RuleDate{}, "", false
+		}
+	}
+
+	if len(s) == 0 || s[0] != '/' {
+		r.Time = 2 * secondsPerHour // 2am is the default
+		return r, s, true
+	}
+
+	r.Time, s, ok = ruleOffset(s[1:])
+	if !ok {
+		return RuleDate{}, "", false
+	}
+	return r, s, true
+}
+
+// ruleNum parses a number from a rule string.
+// It returns the number, and the remainder of the string, and reports success.
+// The number must be between min and max.
+func ruleNum(s string, min, max int) (num int, rest string, ok bool) {
+	if len(s) == 0 {
+		return 0, "", false
+	}
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			if i == 0 || num < min {
+				return 0, "", false
+			}
+			return num, s[i:], true
+		}
+		num *= 10
+		num += int(r) - '0'
+		if num > max {
+			return 0, "", false
+		}
+	}
+	if num < min {
+		return 0, "", false
+	}
+	return num, "", true
+}
+
+// String returns the rule in POSIX TZ format.
+// Default values are omitted, as zic does, so the result
+// may differ from the string the rule was parsed from.
+func (r *Rule) String() string {
+	var b strings.Builder
+	b.WriteString(formatRuleName(r.Std.Name))
+	b.WriteString(formatRuleOffset(-r.Std.Offset))
+	if !r.HasDST {
+		return b.String()
+	}
+	b.WriteString(formatRuleName(r.DST.Name))
+	if r.DST.Offset != r.Std.Offset+secondsPerHour {
+		b.WriteString(formatRuleOffset(-r.DST.Offset))
+	}
+	b.WriteByte(',')
+	b.WriteString(r.Start.String())
+	b.WriteByte(',')
+	b.WriteString(r.End.String())
+	return b.String()
+}
+
+// String returns the date in POSIX TZ format.
+func (d RuleDate) String() string {
+	var s string
+	switch d.Kind {
+	case RuleJulian:
+		s = "J" + strconv.Itoa(d.Day)
+	case RuleDOY:
+		s = strconv.Itoa(d.Day)
+	case RuleMonthWeekDay:
+		s = "M" + strconv.Itoa(d.Month) + "." + strconv.Itoa(d.Week) + "." + strconv.Itoa(d.Day)
+	}
+	if d.Time != 2*secondsPerHour {
+		s += "/" + formatRuleOffset(d.Time)
+	}
+	return s
+}
+
+func formatRuleName(name string) string {
+	for _, r := range name {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z') {
+			return "<" + name + ">"
+		}
+	}
+	if len(name) < 3 {
+		return "<" + name + ">"
+	}
+	return name
+}
+
+func formatRuleOffset(off int) string {
+	s := ""
+	if off < 0 {
+		s = "-"
+		off = -off
+	}
+	s += strconv.Itoa(off / secondsPerHour)
+	if off%secondsPerHour != 0 {
+		m := off / secondsPerMinute % 60
+		s += ":" + strconv.Itoa(m/10) + strconv.Itoa(m%10)
+		if off%secondsPerMinute != 0 {
+			sec := off % secondsPerMinute
+			s += ":" + strconv.Itoa(sec/10) + strconv.Itoa(sec%10)
+		}
+	}
+	return s
+}
+
+const (
+	secondsPerMinute = 60
+	secondsPerHour   = 60 * secondsPerMinute
+	secondsPerDay    = 24 * secondsPerHour
+)
+
+// ruleTime takes a year, a rule date, and a zone offset,
+// and returns the number of seconds since the start of the year
+// that the rule takes effect.
+func ruleTime(year int, d RuleDate, off int) int {
+	var s int
+	switch d.Kind {
+	case RuleJulian:
+		s = (d.Day - 1) * secondsPerDay
+		if isLeap(year) && d.Day >= 60 {
+			s += secondsPerDay
+		}
+	case RuleDOY:
+		s = d.Day * secondsPerDay
+	case RuleMonthWeekDay:
+		// Zeller's Congruence.
+		m1 := (d.Month+9)%12 + 1
+		yy0 := year
+		if d.Month <= 2 {
+			yy0--
+		}
+		yy1 := yy0 / 100
+		yy2 := yy0 % 100
+		dow := ((26*m1-2)/10 + 1 + yy2 + yy2/4 + yy1/4 - 2*yy1) % 7
+		if dow < 0 {
+			dow += 7
+		}
+		// Now dow is the day-of-week of the first day of d.Month.
+		// Get the day-of-month of the first "dow" day.
+		day := d.Day - dow
+		if day < 0 {
+			day += 7
+		}
+		for i := 1; i < d.Week; i++ {
+			if day+7 >= daysIn(d.Month, year) {
+				break
+			}
+			day += 7
+		}
+		day += daysBefore[d.Month-1]
+		if isLeap(year) && d.Month > 2 {
+			day++
+		}
+		s = day * secondsPerDay
+	}
+
+	return s + d.Time - off
+}
+
+// minRuleYear and maxRuleYear are the first and last years in which
+// transitions are generated from a rule. They keep the work for ranges
+// far from the present bounded; other years can't be written as
+// four-digit years anyway.
+const (
+	minRuleYear = 1
+	maxRuleYear = 9999
+)
+
+// ruleSpan is a period of a year during which a single zone of a rule is in effect.
+type ruleSpan struct {
+	start, end int64
+	zone       Zone
+}
+
+// spans returns the periods of the given year, in order, with the zone
+// that is in effect during each of them. Together they cover the whole
+// year in UTC, as the time package evaluates rules one UTC year at a time.
+func (r *Rule) spans(year int) []ruleSpan {
+	ystart := yearStart(year)
+	yend := yearStart(year + 1)
+	if !r.HasDST {
+		return []ruleSpan{{ystart, yend, r.Std}}
+	}
+
+	start := ystart + int64(ruleTime(year, r.Start, r.Std.Offset))
+	end := ystart + int64(ruleTime(year, r.End, r.DST.Offset))
+	outer, inner := r.Std, r.DST
+	// In the southern hemisphere daylight saving time spans
+	// the end of the year.
+	if end < start {
+		start, end = end, start
+		outer, inner = inner, outer
+	}
+	start = clamp(start, ystart, yend)
+	end = clamp(end, ystart, yend)
+
+	spans := make([]ruleSpan, 0, 3)
+	for _, s := range []ruleSpan{{ystart, start, outer}, {start, end, inner}, {end, yend, outer}} {
+		if s.start < s.end {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+func clamp(n, min, max int64) int64 {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+var daysBefore = [...]int{0, 31, 59, 90, 120, 151, 181, 212, 243, 273, 304, 334}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func daysIn(month, year int) int {
+	if month == 2 && isLeap(year) {
+		return 29
+	}
+	if month == 12 {
+		return 31
+	}
+	return daysBefore[month] - daysBefore[month-1]
+}
+
+// yearStart returns the start of the year in UTC,
+// in seconds since 1970 GMT.
+func yearStart(year int) int64 {
+	return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+}
@@ -0,0 +1,147 @@
+package tz
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	cases := []struct {
+		rule string
+		want Rule
+	}{
+		{
+			rule: "EST5",
+			want: Rule{Std: Zone{Name: "EST", Offset: -5 * 3600}},
+		},
+		{
+			rule: "<+0545>-5:45",
+			want: Rule{Std: Zone{Name: "+0545", Offset: 5*3600 + 45*60}},
+		},
+		{
+			rule: "EST5EDT,M3.2.0,M11.1.0",
+			want: Rule{
+				Std:    Zone{Name: "EST", Offset: -5 * 3600},
+				DST:    Zone{Name: "EDT", Offset: -4 * 3600, IsDST: true},
+				HasDST: true,
+				Start:  RuleDate{Kind: RuleMonthWeekDay, Month: 3, Week: 2, Day: 0, Time: 2 * 3600},
+				End:    RuleDate{Kind: RuleMonthWeekDay, Month: 11, Week: 1, Day: 0, Time: 2 * 3600},
+			},
+		},
+		{
+			rule: "IST-2IDT,M3.4.4/26,M10.5.0",
+			want: Rule{
+				Std:    Zone{Name: "IST", Offset: 2 * 3600},
+				DST:    Zone{Name: "IDT", Offset: 3 * 3600, IsDST: true},
+				HasDST: true,
+				Start:  RuleDate{Kind: RuleMonthWeekDay, Month: 3, Week: 4, Day: 4, Time: 26 * 3600},
+				End:    RuleDate{Kind: RuleMonthWeekDay, Month: 10, Week: 5, Day: 0, Time: 2 * 3600},
+			},
+		},
+		{
+			rule: "<-03>3<-02>,M3.5.0/-2,M10.5.0/-1",
+			want: Rule{
+				Std:    Zone{Name: "-03", Offset: -3 * 3600},
+				DST:    Zone{Name: "-02", Offset: -2 * 3600, IsDST: true},
+				HasDST: true,
+				Start:  RuleDate{Kind: RuleMonthWeekDay, Month: 3, Week: 5, Day: 0, Time: -2 * 3600},
+				End:    RuleDate{Kind: RuleMonthWeekDay, Month: 10, Week: 5, Day: 0, Time: -1 * 3600},
+			},
+		},
+		{
+			rule: "EST5EDT4,0/0,J365/25",
+			want: Rule{
+				Std:    Zone{Name: "EST", Offset: -5 * 3600},
+				DST:    Zone{Name: "EDT", Offset: -4 * 3600, IsDST: true},
+				HasDST: true,
+				Start:  RuleDate{Kind: RuleDOY, Day: 0, Time: 0},
+				End:    RuleDate{Kind: RuleJulian, Day: 365, Time: 25 * 3600},
+			},
+		},
+		{
+			rule: "NZST-12NZDT,M9.5.0,M4.1.0/3",
+			want: Rule{
+				Std:    Zone{Name: "NZST", Offset: 12 * 3600},
+				DST:    Zone{Name: "NZDT", Offset: 13 * 3600, IsDST: true},
+				HasDST: true,
+				Start:  RuleDate{Kind: RuleMonthWeekDay, Month: 9, Week: 5, Day: 0, Time: 2 * 3600},
+				End:    RuleDate{Kind: RuleMonthWeekDay, Month: 4, Week: 1, Day: 0, Time: 3 * 3600},
+			},
+		},
+		{
+			rule: "EST5EDT",
+			want: Rule{
+				Std:    Zone{Name: "EST", Offset: -5 * 3600},
+				DST:    Zone{Name: "EDT", Offset: -4 * 3600, IsDST: true},
+				HasDST: true,
+				Start:  RuleDate{Kind: RuleMonthWeekDay, Month: 3, Week: 2, Day: 0, Time: 2 * 3600},
+				End:    RuleDate{Kind: RuleMonthWeekDay, Month: 11, Week: 1, Day: 0, Time: 2 * 3600},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.rule, func(t *testing.T) {
+			r, err := ParseRule(c.rule)
+			if err != nil {
+				t.Fatalf("error parsing rule: %s", err)
+			}
+			if !reflect.DeepEqual(*r, c.want) {
+				t.Fatalf("got %+v, want %+v", *r, c.want)
+			}
+		})
+	}
+}
+
+func TestParseRule_Invalid(t *testing.T) {
+	cases := []string{
+		"",
+		"ES5",
+		"EST",
+		"<EST5",
+		"EST5EDT,M3.2.0",
+		"EST5EDT,M13.2.0,M11.1.0",
+		"EST5EDT,M3.6.0,M11.1.0",
+		"EST5EDT,M3.2.7,M11.1.0",
+		"EST5EDT,J0,J365",
+		"EST5EDT,366,0",
+		"EST5EDT,M3.2.0/169,M11.1.0",
+		"EST5EDT,M3.2.0,M11.1.0x",
+		"EST169",
+	}
+
+	for _, c := range cases {
+		t.Run(c, func(t *testing.T) {
+			if r, err := ParseRule(c); err == nil {
+				t.Fatalf("got %+v, want error", r)
+			}
+		})
+	}
+}
+
+func TestRule_String(t *testing.T) {
+	cases := []string{
+		"EST5",
+		"<+0545>-5:45",
+		"<-00>0",
+		"EST5EDT,M3.2.0,M11.1.0",
+		"IST-2IDT,M3.4.4/26,M10.5.0",
+		"<-03>3<-02>,M3.5.0/-2,M10.5.0/-1",
+		"EST5EDT,0/0,J365/25",
+		"NZST-12NZDT,M9.5.0,M4.1.0/3",
+		"<+1030>-10:30<+11>-11,M10.1.0,M4.1.0",
+		"LMT-0:17:30",
+	}
+
+	for _, c := range cases {
+		t.Run(c, func(t *testing.T) {
+			r, err := ParseRule(c)
+			if err != nil {
+				t.Fatalf("error parsing rule: %s", err)
+			}
+			if s := r.String(); s != c {
+				t.Fatalf("got %s, want %s", s, c)
+			}
+		})
+	}
+}
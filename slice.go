@@ -0,0 +1,40 @@
+package tz
+
+// Slice returns a location that has the zones of l for the instants
+// from <= sec < to, in seconds since January 1, 1970 UTC, and only the
+// zones and transitions needed for them.
+//
+// The location starts with a transition at from to the zone in effect
+// at from. It keeps the footer rule if the window extends past the last
+// transition of l. Leap seconds are kept as they are.
+func (l *Location) Slice(from, to int64) *Location {
+	s := l.clone()
+	s.Tx = nil
+	if len(l.Zone) == 0 {
+		return s
+	}
+
+	zone, _, _ := l.Lookup(from)
+	s.Tx = append(s.Tx, ZoneTrans{When: alpha, Index: s.zoneIndex(zone)})
+	for _, tx := range l.Tx {
+		if from < tx.When && tx.When < to {
+			s.Tx = append(s.Tx, tx)
+		}
+	}
+	extend, rule := s.Extend, s.Rule
+	if len(l.Tx) > 0 && l.Tx[len(l.Tx)-1].When >= to {
+		extend, rule = "", nil
+	}
+
+	// The first transition is at the start of time, and there's no rule,
+	// while normalizing, so it's kept as the zone in effect before the others.
+	s.Rule = nil
+	s = s.Normalize(NormalizeOptions{
+		MergeZones:    true,
+		DropRedundant: true,
+		DropUnused:    true,
+	})
+	s.Tx[0].When = from
+	s.Extend, s.Rule = extend, rule
+	return s
+}
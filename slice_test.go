@@ -0,0 +1,76 @@
+package tz
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlice(t *testing.T) {
+	cases := []struct {
+		name     string
+		from, to int
+	}{
+		{"America/New_York", 2000, 2040},
+		{"America/New_York", 1900, 1950},
+		{"America/New_York", 2050, 2060},
+		{"Australia/Sydney", 2000, 2040},
+		{"Europe/Moscow", 1915, 2030},
+		{"Asia/Kolkata", 1800, 2100},
+		{"Pacific/Apia", 2010, 2013},
+		{"Etc/GMT+5", 2000, 2040},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, err := locationByName(c.name)
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			from := time.Date(c.from, time.July, 1, 0, 0, 0, 0, time.UTC).Unix()
+			to := time.Date(c.to, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+			s := l.Slice(from, to)
+
+			if s.Tx[0].When != from {
+				t.Fatalf("got first transition at %d, want %d", s.Tx[0].When, from)
+			}
+			used := make([]bool, len(s.Zone))
+			for i, tx := range s.Tx {
+				used[tx.Index] = true
+				if i > 0 && (tx.When <= from || tx.When >= to) {
+					t.Fatalf("transition %d at %d is outside the window", i, tx.When)
+				}
+				if i > 0 && sameZone(s.Zone[s.Tx[i-1].Index], s.Zone[tx.Index]) {
+					t.Fatalf("transition %d is redundant", i)
+				}
+			}
+			for i := range used {
+				if !used[i] {
+					t.Fatalf("zone %d is unused", i)
+				}
+			}
+
+			stdlibLoc, err := s.TimeLocation()
+			if err != nil {
+				t.Fatalf("error converting location: %s", err)
+			}
+			check := func(sec int64) {
+				want, _, _ := l.Lookup(sec)
+				if got, _, _ := s.Lookup(sec); !sameZone(got, want) {
+					t.Fatalf("at %d: got %v, want %v", sec, got, want)
+				}
+				if got := timeZone(stdlibLoc, sec); !sameZone(got, want) {
+					t.Fatalf("at %d: time.Location got %v, want %v", sec, got, want)
+				}
+			}
+			check(from)
+			check(to - 1)
+			for sec := from; sec < to; sec += 86400 {
+				check(sec)
+			}
+			for _, tr := range l.Transitions(from, to) {
+				check(tr.When - 1)
+				check(tr.When)
+			}
+		})
+	}
+}
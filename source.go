@@ -0,0 +1,484 @@
+package tz
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Source is time zone database source: the Rule, Zone and Link lines of
+// files such as africa, europe and backward, in the format zic reads.
+// The zero value is an empty source, ready to use.
+type Source struct {
+	rules   map[string][]*sourceRule
+	zones   map[string][]*sourceZone
+	links   map[string]string
+	version string
+}
+
+// SourceError describes a problem found in time zone database source.
+type SourceError struct {
+	File   string // name of the file
+	Line   int    // line number, starting at 1
+	Reason string // what is wrong
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Reason)
+}
+
+// sourceRule is a Rule line, or the UNTIL columns of a Zone line.
+type sourceRule struct {
+	file string
+	line int
+
+	loYear, hiYear     int64
+	loWasNum, hiWasNum bool
+
+	month   int // 0 to 11
+	dayCode int
+	day     int // day of month, or the bound of dayCode
+	weekday int // 0 to 6, Sunday is 0, only if dayCode isn't dayOfMonth
+
+	tod                int64 // time of day, in seconds
+	todIsStd, todIsUTC bool
+
+	save    int64
+	isDST   bool
+	letters string
+}
+
+const (
+	dayOfMonth     = iota // the day of month
+	weekdayOnOrAft        // the first weekday on or after the day, Sun>=8
+	weekdayOnOrBef        // the last weekday on or before the day, lastSun or Sun<=25
+)
+
+// sourceZone is a Zone line or one of its continuation lines.
+type sourceZone struct {
+	file string
+	line int
+
+	stdoff int64
+	rule   string
+	format string
+	// formatVerb is 's' or 'z' if format contains %s or %z.
+	formatVerb byte
+
+	hasUntil  bool
+	until     sourceRule
+	untilTime int64
+}
+
+// yearMin and yearMax are the years "minimum" and "maximum".
+const (
+	yearMin = -1 << 63
+	yearMax = 1<<63 - 1
+)
+
+var (
+	lineNames  = []string{"Rule", "Zone", "Link"}
+	monthNames = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+	dayNames   = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+)
+
+// Parse reads a file of time zone database source and adds its lines to s.
+// The name of the file is used in errors, which are *SourceError values.
+//
+// Keywords, month and day names can be abbreviated, as zic allows,
+// so the compact tzdata.zi file can be read too.
+func (s *Source) Parse(file string, r io.Reader) error {
+	if s.rules == nil {
+		s.rules = make(map[string][]*sourceRule)
+		s.zones = make(map[string][]*sourceZone)
+		s.links = make(map[string]string)
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	var (
+		zone string // the zone whose continuation line is next
+		n    int
+	)
+	for sc.Scan() {
+		n++
+		if v := strings.TrimPrefix(sc.Text(), "# version "); v != sc.Text() && s.version == "" {
+			s.version = strings.TrimSpace(v)
+		}
+		fields, err := sourceFields(sc.Text())
+		if err == nil && len(fields) > 0 {
+			if zone != "" {
+				zone, err = s.zoneLine(file, n, zone, fields)
+			} else {
+				zone, err = s.line(file, n, fields)
+			}
+		}
+		if err != nil {
+			return &SourceError{File: file, Line: n, Reason: err.Error()}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if zone != "" {
+		return &SourceError{File: file, Line: n, Reason: "expected continuation line not found"}
+	}
+	return nil
+}
+
+// line adds a Rule, Zone or Link line. It returns the name of the zone
+// if a continuation line must follow.
+func (s *Source) line(file string, n int, fields []string) (string, error) {
+	switch lookupWord(fields[0], lineNames) {
+	case 0:
+		if len(fields) != 10 {
+			return "", errors.New("wrong number of fields on Rule line")
+		}
+		r, err := parseSourceRule(fields[2], fields[3], fields[4], fields[5], fields[6], fields[7])
+		if err != nil {
+			return "", err
+		}
+		r.file, r.line = file, n
+		r.save, r.isDST, err = parseSave(fields[8])
+		if err != nil {
+			return "", err
+		}
+		r.letters = fields[9]
+		s.rules[fields[1]] = append(s.rules[fields[1]], r)
+		return "", nil
+	case 1:
+		if len(fields) < 5 || len(fields) > 9 {
+			return "", errors.New("wrong number of fields on Zone line")
+		}
+		name := fields[1]
+		if err := s.checkName(name); err != nil {
+			return "", err
+		}
+		s.zones[name] = nil
+		return s.zoneLine(file, n, name, fields[2:])
+	case 2:
+		if len(fields) != 3 {
+			return "", errors.New("wrong number of fields on Link line")
+		}
+		if fields[1] == "" {
+			return "", errors.New("blank TARGET field on Link line")
+		}
+		if err := s.checkName(fields[2]); err != nil {
+			return "", err
+		}
+		s.links[fields[2]] = fields[1]
+		return "", nil
+	}
+	return "", fmt.Errorf("input line of unknown type %q", fields[0])
+}
+
+// checkName returns an error if there already is a zone or link with the name.
+func (s *Source) checkName(name string) error {
+	if name == "" {
+		return errors.New("empty name")
+	}
+	if _, ok := s.zones[name]; ok {
+		return fmt.Errorf("duplicate zone name %s", name)
+	}
+	if _, ok := s.links[name]; ok {
+		return fmt.Errorf("duplicate link name %s", name)
+	}
+	return nil
+}
+
+// zoneLine adds the STDOFF, RULES, FORMAT and UNTIL columns of a Zone line,
+// or of a continuation line, to the zone. It returns the name of the zone
+// if a continuation line must follow.
+func (s *Source) zoneLine(file string, n int, name string, fields []string) (string, error) {
+	if len(fields) < 3 || len(fields) > 7 {
+		return "", errors.New("wrong number of fields on Zone continuation line")
+	}
+	z := &sourceZone{file: file, line: n, rule: fields[1], format: fields[2]}
+	var err error
+	if z.stdoff, err = parseHMS(fields[0]); err != nil {
+		return "", errors.New("invalid UT offset")
+	}
+	if i := strings.IndexByte(z.format, '%'); i >= 0 {
+		if i+1 == len(z.format) || z.format[i+1] != 's' && z.format[i+1] != 'z' ||
+			strings.Contains(z.format[i+2:], "%") || strings.Contains(z.format, "/") {
+			return "", errors.New("invalid abbreviation format")
+		}
+		z.formatVerb = z.format[i+1]
+	}
+	if len(fields) == 3 {
+		s.zones[name] = append(s.zones[name], z)
+		return "", nil
+	}
+
+	until := []string{"", "Jan", "1", "0"}
+	copy(until, fields[3:])
+	r, err := parseSourceRule(until[0], "only", "", until[1], until[2], until[3])
+	if err != nil {
+		return "", err
+	}
+	r.file, r.line = file, n
+	z.hasUntil = true
+	z.until = *r
+	if z.untilTime, err = r.time(r.loYear); err != nil {
+		return "", err
+	}
+	if zones := s.zones[name]; len(zones) > 0 {
+		prev := zones[len(zones)-1].untilTime
+		if alpha < prev && prev < omega && alpha < z.untilTime && z.untilTime < omega && prev >= z.untilTime {
+			return "", errors.New("zone continuation line end time is not after end time of previous line")
+		}
+	}
+	s.zones[name] = append(s.zones[name], z)
+	return name, nil
+}
+
+// parseSourceRule parses the FROM, TO, TYPE, IN, ON and AT columns of
+// a Rule line.
+func parseSourceRule(from, to, typ, month, day, at string) (*sourceRule, error) {
+	var r sourceRule
+
+	// Time of day, with a suffix for standard time or UT.
+	if at != "" {
+		switch at[len(at)-1] {
+		case 's', 'S':
+			r.todIsStd = true
+			at = at[:len(at)-1]
+		case 'w', 'W':
+			at = at[:len(at)-1]
+		case 'g', 'G', 'u', 'U', 'z', 'Z':
+			r.todIsStd, r.todIsUTC = true, true
+			at = at[:len(at)-1]
+		}
+	}
+	var err error
+	if r.tod, err = parseHMS(at); err != nil {
+		return nil, errors.New("invalid time of day")
+	}
+
+	switch lookupWord(from, []string{"minimum", "maximum"}) {
+	case 0:
+		r.loYear = yearMin
+	case 1:
+		r.loYear = yearMax
+	default:
+		if r.loYear, err = strconv.ParseInt(from, 10, 64); err != nil {
+			return nil, errors.New("invalid starting year")
+		}
+		r.loWasNum = true
+	}
+	switch lookupWord(to, []string{"maximum", "only"}) {
+	case 0:
+		r.hiYear = yearMax
+	case 1:
+		r.hiYear = r.loYear
+	default:
+		if r.hiYear, err = strconv.ParseInt(to, 10, 64); err != nil {
+			return nil, errors.New("invalid ending year")
+		}
+		r.hiWasNum = true
+	}
+	if r.loYear > r.hiYear {
+		return nil, errors.New("starting year greater than ending year")
+	}
+	if typ != "" {
+		return nil, fmt.Errorf("year type %q is unsupported; use \"-\" instead", typ)
+	}
+
+	if r.month = lookupWord(month, monthNames); r.month < 0 {
+		return nil, errors.New("invalid month name")
+	}
+
+	// Day of month, such as 1, lastSun, Sun>=8 or Sun<=25.
+	if w := strings.ToLower(day); strings.HasPrefix(w, "last") && len(w) > 4 {
+		r.dayCode = weekdayOnOrBef
+		r.day = daysIn(r.month+1, 2000)
+		if r.weekday = lookupWord(strings.TrimPrefix(day[4:], "-"), dayNames); r.weekday < 0 {
+			return nil, errors.New("invalid weekday name")
+		}
+		return &r, nil
+	}
+	if i := strings.IndexAny(day, "<>"); i >= 0 {
+		r.dayCode = weekdayOnOrAft
+		if day[i] == '<' {
+			r.dayCode = weekdayOnOrBef
+		}
+		if !strings.HasPrefix(day[i+1:], "=") {
+			return nil, errors.New("invalid day of month")
+		}
+		if r.weekday = lookupWord(day[:i], dayNames); r.weekday < 0 {
+			return nil, errors.New("invalid weekday name")
+		}
+		day = day[i+2:]
+	}
+	if r.day, err = strconv.Atoi(day); err != nil || r.day <= 0 || r.day > daysIn(r.month+1, 2000) {
+		return nil, errors.New("invalid day of month")
+	}
+	return &r, nil
+}
+
+// parseSave parses a SAVE column, with an optional suffix for
+// daylight saving time or standard time. Without a suffix,
+// a nonzero saved time is daylight saving time.
+func parseSave(s string) (save int64, isDST bool, err error) {
+	dst := -1
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'd':
+			dst = 1
+			s = s[:len(s)-1]
+		case 's':
+			dst = 0
+			s = s[:len(s)-1]
+		}
+	}
+	if save, err = parseHMS(s); err != nil {
+		return 0, false, errors.New("invalid saved time")
+	}
+	if dst < 0 {
+		return save, save != 0, nil
+	}
+	return save, dst == 1, nil
+}
+
+// parseHMS parses a time such as 2, 1:30, -0:25:21 or 12:00:00.5,
+// rounding fractional seconds to even, and returns it in seconds.
+// The empty string is 0.
+func parseHMS(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	sign := int64(1)
+	if s[0] == '-' {
+		sign = -1
+		s = s[1:]
+	}
+	var frac string
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		s, frac = s[:i], s[i+1:]
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 || frac != "" && len(parts) != 3 {
+		return 0, ErrBadData
+	}
+	var hms [3]int64
+	for i, p := range parts {
+		if p == "" || strings.Trim(p, "0123456789") != "" {
+			return 0, ErrBadData
+		}
+		n, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		hms[i] = n
+	}
+	if hms[1] >= 60 || hms[2] > 60 || hms[0] > (1<<63-1)/secondsPerHour {
+		return 0, ErrBadData
+	}
+	if frac != "" {
+		if strings.Trim(frac, "0123456789") != "" {
+			return 0, ErrBadData
+		}
+		rest := strings.TrimRight(frac[1:], "0")
+		if frac[0] > '5' || frac[0] == '5' && (rest != "" || hms[2]%2 == 1) {
+			hms[2]++
+		}
+	}
+	return sign * (hms[0]*secondsPerHour + hms[1]*secondsPerMinute + hms[2]), nil
+}
+
+// sourceFields splits a line of source into fields, the way zic does.
+// Fields are separated by white space, and can be quoted with double
+// quotes. A # outside quotes starts a comment. A field that is only "-"
+// is empty.
+func sourceFields(line string) ([]string, error) {
+	var fields []string
+	i := 0
+	for {
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) || line[i] == '#' {
+			return fields, nil
+		}
+		var b strings.Builder
+		for i < len(line) && line[i] != '#' && !isSpace(line[i]) {
+			if line[i] != '"' {
+				b.WriteByte(line[i])
+				i++
+				continue
+			}
+			j := strings.IndexByte(line[i+1:], '"')
+			if j < 0 {
+				return nil, errors.New("odd number of quotation marks")
+			}
+			b.WriteString(line[i+1 : i+1+j])
+			i += j + 2
+		}
+		field := b.String()
+		if field == "-" {
+			field = ""
+		}
+		fields = append(fields, field)
+	}
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\f', '\n', '\r', '\t', '\v':
+		return true
+	}
+	return false
+}
+
+// lookupWord returns the index of word in words, or -1.
+// Like zic, it ignores case and accepts an unambiguous prefix.
+func lookupWord(word string, words []string) int {
+	if word == "" {
+		return -1
+	}
+	for i, w := range words {
+		if strings.EqualFold(word, w) {
+			return i
+		}
+	}
+	found := -1
+	for i, w := range words {
+		if len(word) <= len(w) && strings.EqualFold(word, w[:len(word)]) {
+			if found >= 0 {
+				return -1
+			}
+			found = i
+		}
+	}
+	return found
+}
+
+// Version returns the release of the time zone database, such as 2019c,
+// from the "# version" comment that starts tzdata.zi, or "" if the
+// source has no such comment.
+func (s *Source) Version() string {
+	return s.version
+}
+
+// Zones returns the names of the zones in the source, sorted.
+func (s *Source) Zones() []string {
+	names := make([]string, 0, len(s.zones))
+	for name := range s.zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Links returns the links in the source, from the name of each link
+// to the name of its target.
+func (s *Source) Links() map[string]string {
+	links := make(map[string]string, len(s.links))
+	for name, target := range s.links {
+		links[name] = target
+	}
+	return links
+}
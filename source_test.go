@@ -0,0 +1,157 @@
+package tz
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSource_Parse(t *testing.T) {
+	s := testSourceFor(t, testSource)
+
+	wantZones := []string{"America/New_York", "Asia/Kolkata", "Etc/UTC"}
+	if zones := s.Zones(); !reflect.DeepEqual(zones, wantZones) {
+		t.Fatalf("got zones %v, want %v", zones, wantZones)
+	}
+	wantLinks := map[string]string{
+		"US/Eastern":    "America/New_York",
+		"Etc/Universal": "Etc/UTC",
+		"UTC":           "Etc/UTC",
+		"Zulu":          "Etc/Universal",
+	}
+	if links := s.Links(); !reflect.DeepEqual(links, wantLinks) {
+		t.Fatalf("got links %v, want %v", links, wantLinks)
+	}
+	if n := len(s.rules["US"]); n != 13 {
+		t.Fatalf("got %d US rules, want 13", n)
+	}
+	if n := len(s.zones["Asia/Kolkata"]); n != 8 {
+		t.Fatalf("got %d Asia/Kolkata lines, want 8", n)
+	}
+}
+
+func TestSource_ParseErrors(t *testing.T) {
+	cases := []struct {
+		src     string
+		wantErr string
+	}{
+		{"Rule US 1918", "test:1: wrong number of fields on Rule line"},
+		{"# comment\nFoo A B", `test:2: input line of unknown type "Foo"`},
+		{"Zone A 1 - A 1990", "test:1: expected continuation line not found"},
+		{"Zone A 1 - A\nZone A 1 - A", "test:2: duplicate zone name A"},
+		{"Link A B\nZone B 1 - B", "test:2: duplicate link name B"},
+		{"Zone A 1 - \"A", "test:1: odd number of quotation marks"},
+		{"Zone A 1:60 - A", "test:1: invalid UT offset"},
+		{"Zone A 1 - A%d", "test:1: invalid abbreviation format"},
+		{"Zone A 1 - A 2000\n1 - B 1999", "test:2: zone continuation line end time is not after end time of previous line"},
+		{"Rule X 2000 1999 - Jan 1 0 0 -", "test:1: starting year greater than ending year"},
+		{"Rule X 2000 only - Ju 1 0 0 -", "test:1: invalid month name"},
+		{"Rule X 2000 only - Feb 30 0 0 -", "test:1: invalid day of month"},
+		{"Rule X 2000 only - Feb lastFoo 0 0 -", "test:1: invalid weekday name"},
+		{"Rule X 2000 only - Feb Sun>8 0 0 -", "test:1: invalid day of month"},
+		{"Rule X 2000 only odd Feb 1 0 0 -", `test:1: year type "odd" is unsupported; use "-" instead`},
+	}
+
+	for _, c := range cases {
+		var s Source
+		err := s.Parse("test", strings.NewReader(c.src))
+		var srcErr *SourceError
+		if !errors.As(err, &srcErr) || err.Error() != c.wantErr {
+			t.Fatalf("%q: got error %v, want %s", c.src, err, c.wantErr)
+		}
+	}
+}
+
+func TestParseSourceRule(t *testing.T) {
+	cases := []struct {
+		fields []string
+		want   sourceRule
+	}{
+		{
+			[]string{"1987", "2006", "", "Apr", "Sun>=1", "2:00"},
+			sourceRule{loYear: 1987, hiYear: 2006, loWasNum: true, hiWasNum: true, month: 3, dayCode: weekdayOnOrAft, day: 1, tod: 7200},
+		},
+		{
+			[]string{"2007", "max", "", "Mar", "lastSun", "1:00u"},
+			sourceRule{loYear: 2007, hiYear: yearMax, loWasNum: true, month: 2, dayCode: weekdayOnOrBef, day: 31, tod: 3600, todIsStd: true, todIsUTC: true},
+		},
+		{
+			[]string{"1945", "o", "", "Au", "14", "23s"},
+			sourceRule{loYear: 1945, hiYear: 1945, loWasNum: true, month: 7, day: 14, tod: 23 * 3600, todIsStd: true},
+		},
+		{
+			[]string{"mi", "ma", "", "F", "Sa<=29", "24"},
+			sourceRule{loYear: yearMin, hiYear: yearMax, month: 1, dayCode: weekdayOnOrBef, day: 29, weekday: 6, tod: 24 * 3600},
+		},
+	}
+
+	for _, c := range cases {
+		f := c.fields
+		r, err := parseSourceRule(f[0], f[1], f[2], f[3], f[4], f[5])
+		if err != nil {
+			t.Fatalf("%v: error parsing: %s", f, err)
+		}
+		if *r != c.want {
+			t.Fatalf("%v: got %+v, want %+v", f, *r, c.want)
+		}
+	}
+}
+
+func TestParseHMS(t *testing.T) {
+	cases := []struct {
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"2", 7200, false},
+		{"1:30", 5400, false},
+		{"-0:25:21", -1521, false},
+		{"167:00", 167 * 3600, false},
+		{"12:00:00.5", 43200, false},
+		{"12:00:01.5", 43202, false},
+		{"12:00:00.51", 43201, false},
+		{"1:60", 0, true},
+		{"1:2:3:4", 0, true},
+		{"1.5", 0, true},
+		{"1:-2", 0, true},
+		{"a", 0, true},
+	}
+
+	for _, c := range cases {
+		got, err := parseHMS(c.s)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%q: got error %v", c.s, err)
+		}
+		if got != c.want {
+			t.Fatalf("%q: got %d, want %d", c.s, got, c.want)
+		}
+	}
+}
+
+func TestLookupWord(t *testing.T) {
+	cases := []struct {
+		word  string
+		words []string
+		want  int
+	}{
+		{"Rule", lineNames, 0},
+		{"z", lineNames, 1},
+		{"LINK", lineNames, 2},
+		{"Ja", monthNames, 0},
+		{"J", monthNames, -1},
+		{"Ma", monthNames, -1},
+		{"mAy", monthNames, 4},
+		{"Su", dayNames, 0},
+		{"S", dayNames, -1},
+		{"Sundays", dayNames, -1},
+		{"", dayNames, -1},
+	}
+
+	for _, c := range cases {
+		if got := lookupWord(c.word, c.words); got != c.want {
+			t.Fatalf("%q: got %d, want %d", c.word, got, c.want)
+		}
+	}
+}
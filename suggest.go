@@ -0,0 +1,111 @@
+package tz
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of suggestions
+// in an UnknownLocationError.
+const maxSuggestions = 5
+
+// maxSuggestLen is the length of the longest name suggest scores.
+// Embedded names are at most 32 bytes, so longer input
+// has no close match and is not worth comparing.
+const maxSuggestLen = 64
+
+// UnknownLocationError is returned for a location name
+// that has no embedded location.
+type UnknownLocationError struct {
+	Name string
+	// Suggestions are the names of embedded locations close to Name,
+	// best first.
+	Suggestions []string
+}
+
+func (e *UnknownLocationError) Error() string {
+	return "unknown location " + e.Name
+}
+
+// unknownLocation returns an UnknownLocationError for name,
+// with suggestions.
+func unknownLocation(name string) error {
+	return &UnknownLocationError{
+		Name:        name,
+		Suggestions: suggest(name),
+	}
+}
+
+// suggest returns the names of embedded locations close to name, best first:
+// names that only differ in case, names with the same city, ignoring the
+// area, then names with a small edit distance, closest first.
+func suggest(name string) []string {
+	if name == "" || len(name) > maxSuggestLen {
+		return nil
+	}
+	lower := strings.ToLower(name)
+	city := lower[strings.LastIndex(lower, "/")+1:]
+	maxDist := len(lower) / 4
+	if maxDist < 1 {
+		maxDist = 1
+	}
+
+	type match struct {
+		name  string
+		score int
+	}
+	var matches []match
+	for _, n := range Names() {
+		l := strings.ToLower(n)
+		switch {
+		case l == lower:
+			matches = append(matches, match{n, 0})
+		case l[strings.LastIndex(l, "/")+1:] == city:
+			matches = append(matches, match{n, 1})
+		case len(l) > len(lower)+maxDist || len(lower) > len(l)+maxDist:
+			// The edit distance is at least the difference in length.
+		default:
+			if d := editDistance(l, lower); d <= maxDist {
+				matches = append(matches, match{n, 1 + d})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score < matches[j].score })
+
+	var suggestions []string
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, matches[i].name)
+	}
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,87 @@
+package tz
+
+import "time"
+
+// Transition is a change from one zone to another.
+type Transition struct {
+	// When is the instant of the transition,
+	// in seconds since January 1, 1970 UTC.
+	When int64
+	Prev Zone
+	Next Zone
+}
+
+// Transitions returns the transitions at instants from <= When < to,
+// in seconds since January 1, 1970 UTC, in order.
+// After the last transition of the location, the transitions
+// described by the footer rule are returned, up to the end of year 9999.
+// Transitions that don't change the zone are left out.
+func (l *Location) Transitions(from, to int64) []Transition {
+	if len(l.Zone) == 0 || from >= to {
+		return nil
+	}
+
+	var transitions []Transition
+	prev := l.Zone[l.lookupFirstZone()]
+	add := func(when int64, next Zone) {
+		if sameZone(prev, next) {
+			return
+		}
+		if when >= from && when != alpha {
+			transitions = append(transitions, Transition{
+				When: when,
+				Prev: prev,
+				Next: next,
+			})
+		}
+		prev = next
+	}
+
+	for _, tx := range l.Tx {
+		if tx.When >= to {
+			return transitions
+		}
+		add(tx.When, l.Zone[tx.Index])
+	}
+	if l.Rule == nil || len(l.Tx) == 0 {
+		return transitions
+	}
+
+	// Only evaluate the rule for the years in the range, starting
+	// a year early to know the zone in effect at the start of the range.
+	last := l.Tx[len(l.Tx)-1].When
+	if limit := yearStart(maxRuleYear + 1); to > limit {
+		to = limit
+	}
+	first := 1970
+	if last != alpha {
+		first = time.Unix(last, 0).UTC().Year()
+	}
+	if from >= to {
+		return transitions
+	}
+	if from > yearStart(first+1) {
+		first = time.Unix(from, 0).UTC().Year() - 1
+	}
+	end := time.Unix(to-1, 0).UTC().Year()
+	for y := first; y <= end; y++ {
+		for _, s := range l.Rule.spans(y) {
+			if s.start <= last || s.start >= to {
+				continue
+			}
+			add(s.start, l.zoneLike(s.zone))
+		}
+	}
+	return transitions
+}
+
+// zoneLike returns the zone of the location that describes the same
+// local time as zone, or zone if there is none.
+func (l *Location) zoneLike(zone Zone) Zone {
+	for _, z := range l.Zone {
+		if sameZone(z, zone) {
+			return z
+		}
+	}
+	return zone
+}
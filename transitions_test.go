@@ -0,0 +1,141 @@
+package tz
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTransitions(t *testing.T) {
+	cases := []struct {
+		name string
+	}{
+		{"UTC"},
+		{"America/New_York"},
+		{"Australia/Sydney"},
+		{"Europe/London"},
+		{"Asia/Jerusalem"},
+		{"America/Santiago"},
+		{"Etc/GMT+5"},
+	}
+
+	from := time.Date(1850, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, err := locationByName(c.name)
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			stdlibLoc := mustLoadLocation(t, c.name)
+
+			transitions := l.Transitions(from, to)
+			if c.name == "UTC" || c.name == "Etc/GMT+5" {
+				if len(transitions) != 0 {
+					t.Fatalf("got %d transitions, want none", len(transitions))
+				}
+				return
+			}
+			if len(transitions) == 0 || transitions[len(transitions)-1].When < 4e9 {
+				t.Fatalf("got no transitions from the footer rule")
+			}
+			for i, tr := range transitions {
+				if i > 0 && transitions[i-1].When >= tr.When {
+					t.Fatalf("transition %d: out of order", i)
+				}
+				if i > 0 && transitions[i-1].Next != tr.Prev {
+					t.Fatalf("transition %d: got previous zone %v, want %v", i, tr.Prev, transitions[i-1].Next)
+				}
+				if sameZone(tr.Prev, tr.Next) {
+					t.Fatalf("transition %d: doesn't change the zone", i)
+				}
+				for _, c := range []struct {
+					sec  int64
+					want Zone
+				}{{tr.When - 1, tr.Prev}, {tr.When, tr.Next}} {
+					if got := timeZone(stdlibLoc, c.sec); !sameZone(got, c.want) {
+						t.Fatalf("at %d: got %v, want %v", c.sec, got, c.want)
+					}
+				}
+			}
+
+			// Every change of zone is found.
+			want, _, _ := l.Lookup(from)
+			n := 0
+			for sec := from; sec < to; sec += 86400 {
+				for n < len(transitions) && transitions[n].When <= sec {
+					want = transitions[n].Next
+					n++
+				}
+				if got, _, _ := l.Lookup(sec); !sameZone(got, want) {
+					t.Fatalf("at %d: got %v from the transitions, want %v", sec, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTransitions_Range(t *testing.T) {
+	l, err := locationByName("America/New_York")
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	from := time.Date(2050, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(2051, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	transitions := l.Transitions(from, to)
+	want := []Transition{
+		{time.Date(2050, time.March, 13, 7, 0, 0, 0, time.UTC).Unix(), Zone{Name: "EST", Offset: -5 * 3600}, Zone{Name: "EDT", Offset: -4 * 3600, IsDST: true}},
+		{time.Date(2050, time.November, 6, 6, 0, 0, 0, time.UTC).Unix(), Zone{Name: "EDT", Offset: -4 * 3600, IsDST: true}, Zone{Name: "EST", Offset: -5 * 3600}},
+	}
+	if len(transitions) != len(want) {
+		t.Fatalf("got %d transitions, want %d", len(transitions), len(want))
+	}
+	for i := range want {
+		tr := transitions[i]
+		if tr.When != want[i].When || !sameZone(tr.Prev, want[i].Prev) || !sameZone(tr.Next, want[i].Next) {
+			t.Fatalf("transition %d: got %+v, want %+v", i, tr, want[i])
+		}
+	}
+	if got := l.Transitions(want[0].When, want[0].When+1); len(got) != 1 {
+		t.Fatalf("got %d transitions at the instant of a transition, want 1", len(got))
+	}
+	if got := l.Transitions(want[0].When+1, want[1].When); len(got) != 0 {
+		t.Fatalf("got %d transitions between transitions, want 0", len(got))
+	}
+}
+
+func TestTransitions_FarFuture(t *testing.T) {
+	l, err := locationByName("America/New_York")
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+
+	transitions := l.Transitions(0, math.MaxInt64)
+	if len(transitions) == 0 {
+		t.Fatalf("got no transitions")
+	}
+	if last, want := transitions[len(transitions)-1].When, time.Date(9999, time.November, 7, 6, 0, 0, 0, time.UTC).Unix(); last != want {
+		t.Fatalf("got last transition at %d, want %d", last, want)
+	}
+
+	from := time.Date(9000, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	to := time.Date(9001, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	transitions = l.Transitions(from, to)
+	if len(transitions) != 2 {
+		t.Fatalf("got %d transitions in a year, want 2", len(transitions))
+	}
+	stdlibLoc := mustLoadLocation(t, "America/New_York")
+	for _, tr := range transitions {
+		if got := timeZone(stdlibLoc, tr.When); !sameZone(got, tr.Next) {
+			t.Fatalf("at %d: got %v, want %v", tr.When, tr.Next, got)
+		}
+	}
+	if transitions[0].Prev.IsDST || !transitions[0].Next.IsDST {
+		t.Fatalf("got first transition %+v, want the start of daylight saving time", transitions[0])
+	}
+
+	if got := l.Transitions(math.MaxInt64-1, math.MaxInt64); len(got) != 0 {
+		t.Fatalf("got %d transitions past year 9999, want 0", len(got))
+	}
+}
@@ -6,34 +6,75 @@
 // system or from zoneinfo.zip in a local Go installation. Both of these are
 // often missing from some operating systems, especially Windows.
 //
-// This package has the zoneinfo.zip from Go embedded into the package so that
+// This package has the time zone database embedded into the package so that
 // queries to load a location always return the same data regardless of
-// operating system.
+// operating system. The embedded data is compiled from a release of the
+// IANA time zone database by the tzgen command.
 //
 // This package exists because of https://github.com/golang/go/issues/21881.
 package tz
 
 import (
-	"errors"
+	"compress/flate"
+	"io/ioutil"
+	"sort"
+	"strings"
 	"time"
 )
 
-//go:generate rm -fr zoneinfo
-//go:generate unzip -q $GOROOT/lib/time/zoneinfo.zip -d zoneinfo/
-//go:generate go get 4d63.com/embedfiles
-//go:generate embedfiles -out=zoneinfo.go -pkg=tz zoneinfo/
+// To embed another release, download its tzdata tarball from
+// https://www.iana.org/time-zones and run go generate with TZDATA
+// set to the path of the tarball or the directory it was extracted to.
+//go:generate go run ./cmd/tzgen -out zoneinfo.go $TZDATA
 
 func TZData(name string) ([]byte, bool) {
-	data, ok := files["zoneinfo/"+name]
-	return data, ok
+	i := sort.SearchStrings(zoneNames, name)
+	if i == len(zoneNames) || zoneNames[i] != name {
+		return nil, false
+	}
+	p := zonePayloads[i]
+	var start uint32
+	if p > 0 {
+		start = zoneEnds[p-1]
+	}
+	data, err := ioutil.ReadAll(flate.NewReader(strings.NewReader(zoneData[start:zoneEnds[p]])))
+	if err != nil {
+		panic("tz: corrupt embedded data for " + name + ": " + err.Error())
+	}
+	return data, true
+}
+
+// Names returns the names of the embedded locations, sorted.
+func Names() []string {
+	return append([]string(nil), zoneNames...)
+}
+
+// AreaNames returns the names of the embedded locations in the area,
+// such as "America" or "Etc/", sorted.
+func AreaNames(area string) []string {
+	prefix := strings.TrimSuffix(area, "/") + "/"
+	var names []string
+	for _, name := range Names() {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
 func LoadLocation(name string) (*time.Location, error) {
 	if name == "" || name == "UTC" || name == "Local" {
 		return time.LoadLocation(name)
 	}
+	if loc, ok := cached(name); ok {
+		return loc, nil
+	}
 	if tzdata, ok := TZData(name); ok {
-		return time.LoadLocationFromTZData(name, tzdata)
+		loc, err := time.LoadLocationFromTZData(name, tzdata)
+		if err != nil {
+			return nil, err
+		}
+		return addCached(name, loc), nil
 	}
-	return nil, errors.New("unknown location " + name)
+	return nil, unknownLocation(name)
 }
@@ -1,7 +1,10 @@
 package tz
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -103,12 +106,132 @@ func TestLoadLocation_Unknown(t *testing.T) {
 	}
 }
 
+func TestLoadLocation_UnknownSuggestions(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"america/new_york", "America/New_York"},
+		{"new_york", "America/New_York"},
+		{"Sydney", "Australia/Sydney"},
+		{"Europe/Lodnon", "Europe/London"},
+		{"Amercia/Chicago", "America/Chicago"},
+	}
+
+	for _, c := range cases {
+		_, err := LoadLocation(c.name)
+		var unknown *UnknownLocationError
+		if !errors.As(err, &unknown) {
+			t.Fatalf("%s: got error %v, want an UnknownLocationError", c.name, err)
+		}
+		if unknown.Name != c.name {
+			t.Fatalf("%s: got name %s", c.name, unknown.Name)
+		}
+		if len(unknown.Suggestions) == 0 || unknown.Suggestions[0] != c.want {
+			t.Fatalf("%s: got suggestions %v, want %s first", c.name, unknown.Suggestions, c.want)
+		}
+	}
+
+	_, err := LoadLocation("not-a-real-location")
+	if unknown, ok := err.(*UnknownLocationError); !ok || len(unknown.Suggestions) != 0 {
+		t.Fatalf("got error %#v, want an UnknownLocationError without suggestions", err)
+	}
+
+	long := "Europe/" + strings.Repeat("x", 50000)
+	_, err = LoadLocation(long)
+	if unknown, ok := err.(*UnknownLocationError); !ok || len(unknown.Suggestions) != 0 {
+		t.Fatalf("got error %v, want an UnknownLocationError without suggestions", err)
+	}
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+	if len(names) != len(zoneNames) {
+		t.Fatalf("got %d names, want %d", len(names), len(zoneNames))
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("names are not sorted")
+	}
+	for _, name := range names {
+		if _, err := LoadLocation(name); err != nil {
+			t.Fatalf("error loading location %s: %s", name, err)
+		}
+	}
+}
+
+func TestAreaNames(t *testing.T) {
+	cases := []struct {
+		area  string
+		count int
+		first string
+	}{
+		{"Etc/", 35, "Etc/GMT"},
+		{"Etc", 35, "Etc/GMT"},
+		{"America/Argentina", 13, "America/Argentina/Buenos_Aires"},
+		{"Mars", 0, ""},
+	}
+
+	for _, c := range cases {
+		names := AreaNames(c.area)
+		if len(names) != c.count {
+			t.Fatalf("%s: got %d names, want %d", c.area, len(names), c.count)
+		}
+		if c.count > 0 && names[0] != c.first {
+			t.Fatalf("%s: got first name %s, want %s", c.area, names[0], c.first)
+		}
+		for _, name := range names {
+			if !strings.HasPrefix(name, strings.TrimSuffix(c.area, "/")+"/") {
+				t.Fatalf("%s: got name %s", c.area, name)
+			}
+		}
+	}
+}
+
+func TestTZData_Shared(t *testing.T) {
+	cases := [][]string{
+		{"Etc/UTC", "UTC", "Zulu", "Etc/Universal"},
+		{"America/New_York", "US/Eastern"},
+	}
+	for _, names := range cases {
+		want, ok := TZData(names[0])
+		if !ok {
+			t.Fatalf("%s: not found", names[0])
+		}
+		p := zonePayloads[sort.SearchStrings(zoneNames, names[0])]
+		for _, name := range names[1:] {
+			if got, _ := TZData(name); string(got) != string(want) {
+				t.Errorf("%s: data differs from %s", name, names[0])
+			}
+			if zonePayloads[sort.SearchStrings(zoneNames, name)] != p {
+				t.Errorf("%s: payload isn't shared with %s", name, names[0])
+			}
+		}
+	}
+	if len(zoneEnds) >= len(zoneNames) {
+		t.Errorf("got %d payloads for %d locations", len(zoneEnds), len(zoneNames))
+	}
+}
+
 func BenchmarkLoadLocation(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		LoadLocation("US/Central")
 	}
 }
 
+func BenchmarkLoadLocation_Uncached(b *testing.B) {
+	defer SetCacheSize(DefaultCacheSize)
+	SetCacheSize(0)
+	for i := 0; i < b.N; i++ {
+		LoadLocation("US/Central")
+	}
+}
+
+func BenchmarkTZData(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		TZData("Europe/Budapest")
+	}
+}
+
 func BenchmarkLoadLocation_StdLib(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		time.LoadLocation("US/Central")
@@ -0,0 +1,484 @@
+package tz
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// WriteTZif writes the location to w in the TZif format described
+// in RFC 8536, using the given format version, 1 to 4.
+//
+// Version 1 data only covers the 32-bit time range and has no footer.
+// Version 2 and later data is written the way zic writes it, so
+// writing an unmodified location parsed from zic output reproduces
+// the original bytes.
+// The standard/wall and UT/local indicators are written from the zones;
+// those copied to the transitions are ignored.
+func WriteTZif(w io.Writer, l *Location, version int) error {
+	if version < 1 || version > 4 {
+		return errors.New("unsupported time zone information version")
+	}
+	if len(l.Zone) == 0 || len(l.Zone) > 256 {
+		return errors.New("time zone information must have 1 to 256 zones")
+	}
+	for _, tx := range l.Tx {
+		if int(tx.Index) >= len(l.Zone) {
+			return errors.New("time zone transition has zone index out of range")
+		}
+	}
+	l = l.allTimeZoneFirst()
+	footer := l.footer()
+	if version < 3 && l.Rule != nil && l.Rule.needsV3() {
+		return errors.New("time zone rule needs version 3")
+	}
+	if version < 4 && l.leapNeedsV4() {
+		return errors.New("leap second table needs version 4")
+	}
+
+	var buf bytes.Buffer
+	if err := l.block32().encode(&buf, version, false); err != nil {
+		return err
+	}
+	if version > 1 {
+		if err := l.block64().encode(&buf, version, true); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		buf.WriteString(footer)
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// MarshalBinary encodes the location in the TZif format,
+// using the version it was parsed from, or version 2
+// if the location has no version.
+func (l *Location) MarshalBinary() ([]byte, error) {
+	version := l.Version
+	if version == 0 {
+		version = 2
+		if l.Rule != nil && l.Rule.needsV3() {
+			version = 3
+		}
+		if l.leapNeedsV4() {
+			version = 4
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteTZif(&buf, l, version); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the location with the one
+// parsed from the TZif data, keeping its name.
+func (l *Location) UnmarshalBinary(data []byte) error {
+	parsed, err := ParseLocation(l.Name, data)
+	if err != nil {
+		return err
+	}
+	*l = *parsed
+	return nil
+}
+
+// TimeLocation returns a *time.Location with the location's name, zones,
+// transitions and footer rule, for use with time.Time.
+//
+// The time package doesn't support leap seconds, so they are dropped.
+// A location without zones is UTC.
+func (l *Location) TimeLocation() (*time.Location, error) {
+	tl := l.clone()
+	tl.Leap = nil
+	if len(tl.Zone) == 0 {
+		tl.Zone = []Zone{{Name: "UTC"}}
+	}
+	version := 2
+	if tl.Rule != nil && tl.Rule.needsV3() {
+		version = 3
+	}
+	var buf bytes.Buffer
+	if err := WriteTZif(&buf, tl, version); err != nil {
+		return nil, err
+	}
+	return time.LoadLocationFromTZData(l.Name, buf.Bytes())
+}
+
+// footer returns the POSIX TZ rule to write after the 64-bit data.
+// Extend is used if it matches the rule, so it is written back unchanged.
+func (l *Location) footer() string {
+	if l.Rule == nil {
+		return l.Extend
+	}
+	if r, err := ParseRule(l.Extend); err == nil && reflect.DeepEqual(r, l.Rule) {
+		return l.Extend
+	}
+	return l.Rule.String()
+}
+
+// needsV3 reports whether the rule uses the version 3 extension
+// of transition times outside 0 to 24 hours.
+func (r *Rule) needsV3() bool {
+	if !r.HasDST {
+		return false
+	}
+	for _, d := range []RuleDate{r.Start, r.End} {
+		if d.Time < 0 || d.Time > 24*secondsPerHour {
+			return true
+		}
+	}
+	return false
+}
+
+// leapNeedsV4 reports whether the leap second table is truncated
+// or expires, which is only allowed in version 4 data.
+func (l *Location) leapNeedsV4() bool {
+	_, expires := l.LeapExpires()
+	return l.LeapTruncated() || expires
+}
+
+// tzifBlock is the data of one TZif data block.
+type tzifBlock struct {
+	zone   []Zone
+	tx     []ZoneTrans
+	leap   []LeapSecond
+	abbrev []byte
+}
+
+// allTimeZoneFirst returns the location with the zone of its fake
+// transition covering all time, if it has one, moved to the front.
+// The fake transition isn't written, and the zone written first is
+// the zone in effect before the first transition.
+func (l *Location) allTimeZoneFirst() *Location {
+	if len(l.Tx) == 0 || l.Tx[0].When != alpha || l.Tx[0].Index == 0 {
+		return l
+	}
+	c := l.clone()
+	i := c.Tx[0].Index
+	c.Zone[0], c.Zone[i] = c.Zone[i], c.Zone[0]
+	for j := range c.Tx {
+		switch c.Tx[j].Index {
+		case 0:
+			c.Tx[j].Index = i
+		case i:
+			c.Tx[j].Index = 0
+		}
+	}
+	return c
+}
+
+// transitions returns the transitions to write,
+// without the fake transition covering all time.
+func (l *Location) transitions() []ZoneTrans {
+	tx := l.Tx
+	for len(tx) > 0 && tx[0].When == alpha {
+		tx = tx[1:]
+	}
+	return tx
+}
+
+// block64 returns the data for the 64-bit block, which has every zone,
+// transition and leap second.
+func (l *Location) block64() *tzifBlock {
+	return l.block(l.transitions(), l.Leap, false)
+}
+
+// block32 returns the data for the 32-bit block, the same way zic
+// derives it from the 64-bit data.
+// Transitions and leap seconds outside the 32-bit range are dropped,
+// except for the last transition before the range, which is moved to
+// its start.
+func (l *Location) block32() *tzifBlock {
+	var leap []LeapSecond
+	for _, ls := range l.Leap {
+		if -1<<31 <= ls.When && ls.When <= 1<<31-1 {
+			leap = append(leap, ls)
+		}
+	}
+	return l.block(l.transitions32(), leap, true)
+}
+
+// transitions32 returns the transitions to write to the 32-bit block.
+func (l *Location) transitions32() []ZoneTrans {
+	const (
+		lo = -1 << 31
+		hi = 1<<31 - 1
+	)
+
+	tx := l.transitions()
+	start, end := 0, len(tx)
+	for start < end && tx[start].When < lo {
+		start++
+	}
+	for start < end && tx[end-1].When > hi {
+		end--
+	}
+	// Keep the last transition before the range.
+	if start > 0 {
+		start--
+	}
+	tx = append([]ZoneTrans(nil), tx[start:end]...)
+	for i := range tx {
+		if tx[i].When < lo {
+			tx[i].When = lo
+		}
+	}
+	return tx
+}
+
+// block returns a data block with the given transitions and leap seconds,
+// with the zones ordered the way zic orders them.
+//
+// zic numbers zones in the order it creates them, and writes them with
+// the zone in effect before the first transition swapped to the front.
+// The abbreviations are written in creation order. If omitUnused is true,
+// zones no transition uses are dropped, except for the first one.
+func (l *Location) block(tx []ZoneTrans, leap []LeapSecond, omitUnused bool) *tzifBlock {
+	// types are the zones in creation order,
+	// created maps zone indexes to creation indexes.
+	def := l.firstCreated()
+	types := append([]Zone(nil), l.Zone...)
+	types[0], types[def] = types[def], types[0]
+	created := make([]int, len(l.Zone))
+	for i := range created {
+		created[i] = i
+	}
+	created[0], created[def] = def, 0
+
+	omit := make([]bool, len(types))
+	if omitUnused {
+		for i := range omit {
+			omit[i] = true
+		}
+		omit[def] = false
+		for _, t := range tx {
+			omit[created[t.Index]] = false
+		}
+	}
+	old0 := 0
+	for omit[old0] {
+		old0++
+	}
+	// swap returns the creation index of the zone written at position i,
+	// counting omitted zones.
+	swap := func(i int) int {
+		switch i {
+		case old0:
+			return def
+		case def:
+			return old0
+		}
+		return i
+	}
+
+	if omitUnused {
+		types, omit = addLastUsedCopies(types, omit, tx, created, old0, swap)
+	}
+
+	b := &tzifBlock{leap: leap}
+	position := make([]int, len(types))
+	for i := old0; i < len(types); i++ {
+		if h := swap(i); !omit[h] {
+			position[h] = len(b.zone)
+			b.zone = append(b.zone, types[h])
+		}
+	}
+	for i := old0; i < len(types); i++ {
+		if !omit[i] {
+			b.abbrev, _ = addAbbrev(b.abbrev, types[i].Name)
+		}
+	}
+	for _, t := range tx {
+		t.Index = uint8(position[created[t.Index]])
+		b.tx = append(b.tx, t)
+	}
+	return b
+}
+
+// firstCreated returns the index of the zone zic created first.
+// It is the zone with the first abbreviation in the parsed data,
+// or zone 0 if that's not known.
+func (l *Location) firstCreated() int {
+	if !l.abbrevValid() || l.Zone[0].Name == byteString([]byte(l.abbrev)) {
+		return 0
+	}
+	for i, zone := range l.Zone {
+		if zone.Name == byteString([]byte(l.abbrev)) {
+			return i
+		}
+	}
+	return 0
+}
+
+// abbrevValid reports whether the abbreviation table of the parsed data
+// is still the table for the location's zones.
+func (l *Location) abbrevValid() bool {
+	if l.abbrev == "" {
+		return false
+	}
+	for _, zone := range l.Zone {
+		if abbrevIndex([]byte(l.abbrev), zone.Name) < 0 {
+			return false
+		}
+	}
+	for _, name := range strings.Split(strings.TrimSuffix(l.abbrev, "\x00"), "\x00") {
+		used := false
+		for _, zone := range l.Zone {
+			used = used || zone.Name == name
+		}
+		if !used {
+			return false
+		}
+	}
+	return true
+}
+
+// addLastUsedCopies adds copies of the most recently used standard and
+// daylight saving zones, if zic would have added them to the 32-bit data.
+// For some pre-2011 systems, zic appends an unused copy of the most
+// recently used zone if the last zone of the same kind has a different
+// offset, to get their global altzone and timezone variables set correctly.
+// Like zic, it compares the position of the last zone with the creation
+// index of the most recently used one.
+func addLastUsedCopies(types []Zone, omit []bool, tx []ZoneTrans, created []int, old0 int, swap func(int) int) ([]Zone, []bool) {
+	mruDST, mruStd := -1, -1
+	for _, t := range tx {
+		if h := created[t.Index]; types[h].IsDST {
+			mruDST = h
+		} else {
+			mruStd = h
+		}
+	}
+	hiDST, hiStd := -1, -1
+	for i := old0; i < len(types); i++ {
+		if h := swap(i); !omit[h] {
+			if types[h].IsDST {
+				hiDST = i
+			} else {
+				hiStd = i
+			}
+		}
+	}
+	for _, c := range [][2]int{{hiDST, mruDST}, {hiStd, mruStd}} {
+		hi, mru := c[0], c[1]
+		if hi < 0 || mru < 0 || hi == mru || types[hi].Offset == types[mru].Offset {
+			continue
+		}
+		found := false
+		for i, zone := range types {
+			if i != mru && zone == types[mru] {
+				omit[i] = false
+				found = true
+				break
+			}
+		}
+		if !found {
+			types = append(types, types[mru])
+			omit = append(omit, false)
+		}
+	}
+	return types, omit
+}
+
+// encode writes the header and data of the block to buf.
+func (b *tzifBlock) encode(buf *bytes.Buffer, version int, is64 bool) error {
+	if len(b.abbrev) > 256 {
+		return errors.New("time zone abbreviations too long")
+	}
+
+	var isstd, isutc int
+	for _, zone := range b.zone {
+		if zone.Isstd {
+			isstd = len(b.zone)
+		}
+		if zone.Isutc {
+			isutc = len(b.zone)
+		}
+	}
+
+	buf.WriteString("TZif")
+	if version == 1 {
+		buf.WriteByte(0)
+	} else {
+		buf.WriteByte('0' + byte(version))
+	}
+	buf.Write(make([]byte, 15))
+	for _, n := range []int{isutc, isstd, len(b.leap), len(b.tx), len(b.zone), len(b.abbrev)} {
+		putBig4(buf, uint32(n))
+	}
+
+	putTime := func(t int64) {
+		if is64 {
+			putBig4(buf, uint32(uint64(t)>>32))
+		}
+		putBig4(buf, uint32(t))
+	}
+	for _, t := range b.tx {
+		putTime(t.When)
+	}
+	for _, t := range b.tx {
+		buf.WriteByte(t.Index)
+	}
+	for _, zone := range b.zone {
+		putBig4(buf, uint32(int32(zone.Offset)))
+		buf.WriteByte(boolByte(zone.IsDST))
+		buf.WriteByte(byte(abbrevIndex(b.abbrev, zone.Name)))
+	}
+	buf.Write(b.abbrev)
+	for _, leap := range b.leap {
+		putTime(leap.When)
+		putBig4(buf, uint32(int32(leap.Correction)))
+	}
+	if isstd > 0 {
+		for _, zone := range b.zone {
+			buf.WriteByte(boolByte(zone.Isstd))
+		}
+	}
+	if isutc > 0 {
+		for _, zone := range b.zone {
+			buf.WriteByte(boolByte(zone.Isutc))
+		}
+	}
+	return nil
+}
+
+// addAbbrev adds the abbreviation to the NUL-terminated abbreviations
+// in abbrev, and returns them and the index of the abbreviation.
+// Like zic, an abbreviation that is a suffix of one already
+// in the table is not added again.
+func addAbbrev(abbrev []byte, name string) ([]byte, int) {
+	if i := abbrevIndex(abbrev, name); i >= 0 {
+		return abbrev, i
+	}
+	i := len(abbrev)
+	abbrev = append(abbrev, name...)
+	abbrev = append(abbrev, 0)
+	return abbrev, i
+}
+
+// abbrevIndex returns the index of the abbreviation in the
+// NUL-terminated abbreviations in abbrev, or -1.
+func abbrevIndex(abbrev []byte, name string) int {
+	for j := range abbrev {
+		if byteString(abbrev[j:]) == name {
+			return j
+		}
+	}
+	return -1
+}
+
+func putBig4(buf *bytes.Buffer, n uint32) {
+	buf.Write([]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)})
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
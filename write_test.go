@@ -0,0 +1,351 @@
+package tz
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestMarshalBinary_RoundTrip(t *testing.T) {
+	for _, name := range zoneNames {
+		t.Run(name, func(t *testing.T) {
+			locData, ok := TZData(name)
+			if !ok {
+				t.Fatalf("error loading timezone data")
+			}
+			l, err := ParseLocation(name, locData)
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			data, err := l.MarshalBinary()
+			if err != nil {
+				t.Fatalf("error encoding location: %s", err)
+			}
+			if !bytes.Equal(data, locData) {
+				t.Fatalf("encoded data differs from the original")
+			}
+		})
+	}
+}
+
+func TestMarshalBinary_Leap(t *testing.T) {
+	locData, err := ioutil.ReadFile("testdata/right/UTC")
+	if err != nil {
+		t.Fatalf("error reading timezone data: %s", err)
+	}
+	l, err := ParseLocation("right/UTC", locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	data, err := l.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error encoding location: %s", err)
+	}
+	if !bytes.Equal(data, locData) {
+		t.Fatalf("encoded data differs from the original")
+	}
+}
+
+func TestWriteTZif_Versions(t *testing.T) {
+	name := "America/New_York"
+	locData, ok := TZData(name)
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+	l, err := ParseLocation(name, locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+
+	wantLoc := mustLoadLocation(t, name)
+	for version := 1; version <= 4; version++ {
+		var buf bytes.Buffer
+		if err := WriteTZif(&buf, l, version); err != nil {
+			t.Fatalf("version %d: error encoding location: %s", version, err)
+		}
+		parsed, err := ParseLocation(name, buf.Bytes())
+		if err != nil {
+			t.Fatalf("version %d: error parsing location: %s", version, err)
+		}
+		if parsed.Version != version {
+			t.Fatalf("version %d: got version %d", version, parsed.Version)
+		}
+		if version == 4 {
+			// The time package doesn't support version 4.
+			continue
+		}
+		stdlibLoc, err := time.LoadLocationFromTZData(name, buf.Bytes())
+		if err != nil {
+			t.Fatalf("version %d: error loading stdlib location: %s", version, err)
+		}
+		for sec := int64(-1 << 31); sec < 1<<31-1; sec += 86400 {
+			got := time.Unix(sec, 0).In(stdlibLoc).Format(time.RFC3339)
+			want := time.Unix(sec, 0).In(wantLoc).Format(time.RFC3339)
+			if got != want {
+				t.Fatalf("version %d: got %s, want %s", version, got, want)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTZif(&buf, l, 5); err == nil {
+		t.Fatalf("got no error for version 5")
+	}
+}
+
+func TestWriteTZif_RuleNeedsV3(t *testing.T) {
+	name := "Asia/Jerusalem"
+	locData, ok := TZData(name)
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+	l, err := ParseLocation(name, locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTZif(&buf, l, 2); err == nil {
+		t.Fatalf("got no error for version 2")
+	}
+	if err := WriteTZif(&buf, l, 3); err != nil {
+		t.Fatalf("error encoding location: %s", err)
+	}
+}
+
+func TestMarshalBinary_Modified(t *testing.T) {
+	name := "America/New_York"
+	locData, ok := TZData(name)
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+	l, err := ParseLocation(name, locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+
+	// Keep the transitions up to 2007, the footer rule covers the rest.
+	cutoff := time.Date(2008, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+	slim := *l
+	slim.Tx = nil
+	for _, tx := range l.Tx {
+		if tx.When < cutoff {
+			slim.Tx = append(slim.Tx, tx)
+		}
+	}
+	slim.Version = 0
+
+	data, err := slim.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error encoding location: %s", err)
+	}
+	stdlibLoc, err := time.LoadLocationFromTZData(name, data)
+	if err != nil {
+		t.Fatalf("error loading stdlib location: %s", err)
+	}
+	wantLoc := mustLoadLocation(t, name)
+	for sec := int64(-3e9); sec < 4e9; sec += 3600 {
+		got := time.Unix(sec, 0).In(stdlibLoc).Format(time.RFC3339)
+		want := time.Unix(sec, 0).In(wantLoc).Format(time.RFC3339)
+		if got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestWriteTZif_BadIndex(t *testing.T) {
+	l := &Location{
+		Zone: []Zone{{Name: "AAA"}},
+		Tx:   []ZoneTrans{{When: 0, Index: 1}},
+	}
+	err := WriteTZif(ioutil.Discard, l, 2)
+	if err == nil {
+		t.Fatalf("got no error for a transition to a missing zone")
+	}
+	if errors.Is(err, ErrBadData) {
+		t.Fatalf("got parse error %v for a location being written", err)
+	}
+}
+
+func TestMarshalBinary_TransitionIndicators(t *testing.T) {
+	name := "America/New_York"
+	locData, ok := TZData(name)
+	if !ok {
+		t.Fatalf("error loading timezone data")
+	}
+	l, err := ParseLocation(name, locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+
+	// The indicators are written from the zones.
+	for i := range l.Tx {
+		l.Tx[i].Isstd = !l.Tx[i].Isstd
+		l.Tx[i].Isutc = !l.Tx[i].Isutc
+	}
+	data, err := l.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error encoding location: %s", err)
+	}
+	if !bytes.Equal(data, locData) {
+		t.Fatalf("encoded data differs from the original")
+	}
+}
+
+func TestMarshalBinary_AllTimeTransition(t *testing.T) {
+	l := &Location{
+		Name: "Test",
+		Zone: []Zone{{Name: "AAA", Offset: 3600}, {Name: "BBB", Offset: 7200}},
+		Tx:   []ZoneTrans{{When: alpha, Index: 1}},
+	}
+	if zone, _, _ := l.Lookup(0); zone.Name != "BBB" {
+		t.Fatalf("got zone %s, want BBB", zone.Name)
+	}
+
+	data, err := l.MarshalBinary()
+	if err != nil {
+		t.Fatalf("error encoding location: %s", err)
+	}
+	parsed, err := ParseLocation("Test", data)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	if zone, _, _ := parsed.Lookup(0); zone.Name != "BBB" {
+		t.Fatalf("got zone %s after a round trip, want BBB", zone.Name)
+	}
+
+	tl, err := l.TimeLocation()
+	if err != nil {
+		t.Fatalf("error converting location: %s", err)
+	}
+	if name, offset := time.Unix(0, 0).In(tl).Zone(); name != "BBB" || offset != 7200 {
+		t.Fatalf("got time.Location zone %s %d, want BBB 7200", name, offset)
+	}
+	if l.Zone[0].Name != "AAA" || l.Tx[0].Index != 1 {
+		t.Fatalf("encoding modified the location")
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := LoadLocation(name)
+	if err != nil {
+		t.Fatalf("error loading location: %s", err)
+	}
+	return loc
+}
+
+func TestTimeLocation(t *testing.T) {
+	for _, name := range zoneNames {
+		t.Run(name, func(t *testing.T) {
+			locData, ok := TZData(name)
+			if !ok {
+				t.Fatalf("error loading timezone data")
+			}
+			l, err := ParseLocation(name, locData)
+			if err != nil {
+				t.Fatalf("error parsing location: %s", err)
+			}
+			loc, err := l.TimeLocation()
+			if err != nil {
+				t.Fatalf("error converting location: %s", err)
+			}
+			if loc.String() != name {
+				t.Fatalf("got name %s, want %s", loc, name)
+			}
+			wantLoc := mustLoadLocation(t, name)
+			check := func(sec int64) {
+				got := time.Unix(sec, 0).In(loc)
+				want := time.Unix(sec, 0).In(wantLoc)
+				gotName, gotOffset := got.Zone()
+				wantName, wantOffset := want.Zone()
+				if gotName != wantName || gotOffset != wantOffset || got.IsDST() != want.IsDST() {
+					t.Fatalf("at %d: got %s %d, want %s %d", sec, gotName, gotOffset, wantName, wantOffset)
+				}
+			}
+			check(-1 << 40)
+			for _, tx := range l.Tx {
+				if tx.When != alpha {
+					check(tx.When - 1)
+					check(tx.When)
+				}
+			}
+			for sec := int64(2e9); sec < 5e9; sec += 30 * 86400 {
+				check(sec)
+			}
+		})
+	}
+}
+
+func TestTimeLocation_Modified(t *testing.T) {
+	name := "America/New_York"
+	l, err := locationByName(name)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+
+	// Move the 2019 spring transition a week later.
+	spring := time.Date(2019, time.March, 10, 7, 0, 0, 0, time.UTC).Unix()
+	patched := l.clone()
+	for i := range patched.Tx {
+		if patched.Tx[i].When == spring {
+			patched.Tx[i].When += 7 * 86400
+		}
+	}
+	patched.Name = "Patched"
+	loc, err := patched.TimeLocation()
+	if err != nil {
+		t.Fatalf("error converting location: %s", err)
+	}
+	if loc.String() != "Patched" {
+		t.Fatalf("got name %s", loc)
+	}
+	for _, c := range []struct {
+		sec  int64
+		want string
+	}{
+		{spring, "EST"},
+		{spring + 7*86400 - 1, "EST"},
+		{spring + 7*86400, "EDT"},
+	} {
+		if got, _ := time.Unix(c.sec, 0).In(loc).Zone(); got != c.want {
+			t.Fatalf("at %d: got %s, want %s", c.sec, got, c.want)
+		}
+	}
+}
+
+func TestTimeLocation_Leap(t *testing.T) {
+	locData, err := ioutil.ReadFile("testdata/right/UTC")
+	if err != nil {
+		t.Fatalf("error reading timezone data: %s", err)
+	}
+	l, err := ParseLocation("right/UTC", locData)
+	if err != nil {
+		t.Fatalf("error parsing location: %s", err)
+	}
+	// Keep the leap seconds from 2000, with a version 4 truncated table.
+	for len(l.Leap) > 0 && l.Leap[0].When < 946684800 {
+		l.Leap = l.Leap[1:]
+	}
+	l.Version = 4
+	loc, err := l.TimeLocation()
+	if err != nil {
+		t.Fatalf("error converting location: %s", err)
+	}
+	if name, offset := time.Unix(1e9, 0).In(loc).Zone(); name != "UTC" || offset != 0 {
+		t.Fatalf("got %s %d, want UTC 0", name, offset)
+	}
+}
+
+func TestTimeLocation_NoZones(t *testing.T) {
+	l := &Location{Name: "Empty"}
+	loc, err := l.TimeLocation()
+	if err != nil {
+		t.Fatalf("error converting location: %s", err)
+	}
+	if name, offset := time.Unix(0, 0).In(loc).Zone(); name != "UTC" || offset != 0 {
+		t.Fatalf("got %s %d, want UTC 0", name, offset)
+	}
+}
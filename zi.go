@@ -0,0 +1,58 @@
+package tz
+
+import (
+	"io"
+	"sort"
+)
+
+// ParseZI reads tzdata.zi, the compact text form of the time zone
+// database that IANA ships and many systems install in /usr/share/zoneinfo,
+// and compiles every zone in it the way zic does.
+//
+// It returns a location for each zone and link, by name, and the links,
+// from the name of each link to the name of its target. The location of
+// a link is the location of the zone it links to, with the name of the
+// link. Use the TimeLocation method to get a *time.Location.
+// Errors in the source are *SourceError values.
+func ParseZI(r io.Reader) (map[string]*Location, map[string]string, error) {
+	var s Source
+	if err := s.Parse("tzdata.zi", r); err != nil {
+		return nil, nil, err
+	}
+	zones := s.Zones()
+	links := s.Links()
+	locations := make(map[string]*Location, len(zones)+len(links))
+	for _, name := range zones {
+		l, err := s.Compile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		locations[name] = l
+	}
+
+	names := make([]string, 0, len(links))
+	for name := range links {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		target := name
+		for i := 0; i <= len(links); i++ {
+			next, ok := links[target]
+			if !ok {
+				break
+			}
+			target = next
+		}
+		zone, ok := locations[target]
+		if !ok {
+			// Compile reports what's wrong with the link.
+			_, err := s.Compile(name)
+			return nil, nil, err
+		}
+		l := zone.clone()
+		l.Name = name
+		locations[name] = l
+	}
+	return locations, links, nil
+}
@@ -0,0 +1,82 @@
+package tz
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseZI(t *testing.T) {
+	locations, links, err := ParseZI(strings.NewReader("# version 2019c\n" + testSource))
+	if err != nil {
+		t.Fatalf("error parsing: %s", err)
+	}
+	wantLinks := map[string]string{
+		"US/Eastern":    "America/New_York",
+		"Etc/Universal": "Etc/UTC",
+		"UTC":           "Etc/UTC",
+		"Zulu":          "Etc/Universal",
+	}
+	if !reflect.DeepEqual(links, wantLinks) {
+		t.Fatalf("got links %v, want %v", links, wantLinks)
+	}
+	if len(locations) != 7 {
+		t.Fatalf("got %d locations, want 7", len(locations))
+	}
+
+	for name, l := range locations {
+		if l.Name != name {
+			t.Fatalf("%s: got name %s", name, l.Name)
+		}
+		data, _ := TZData(name)
+		want, err := ParseLocation(name, data)
+		if err != nil {
+			t.Fatalf("%s: error parsing embedded location: %s", name, err)
+		}
+		if d := Diff(l, want, -1<<40, 1<<36); len(d) > 0 {
+			t.Fatalf("%s: differs from embedded location: %v", name, d[0])
+		}
+	}
+
+	loc, err := locations["US/Eastern"].TimeLocation()
+	if err != nil {
+		t.Fatalf("error converting location: %s", err)
+	}
+	got := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC).In(loc).Format(time.RFC3339 + " MST")
+	if want := "2009-11-10T18:00:00-05:00 EST"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestParseZI_Errors(t *testing.T) {
+	cases := []struct {
+		src     string
+		wantErr string
+	}{
+		{"Z A 1 - A\nZ B", "tzdata.zi:2: wrong number of fields on Zone line"},
+		{"Z A 1 X A", "tzdata.zi:1: unknown rule X"},
+		{"L A B", "link B targets unknown zone A"},
+		{"L A B\nL B A", "link A is part of a link cycle"},
+	}
+
+	for _, c := range cases {
+		_, _, err := ParseZI(strings.NewReader(c.src))
+		if err == nil || err.Error() != c.wantErr {
+			t.Fatalf("%q: got error %v, want %s", c.src, err, c.wantErr)
+		}
+	}
+}
+
+func TestSource_Version(t *testing.T) {
+	var s Source
+	if err := s.Parse("tzdata.zi", strings.NewReader("# version 2019c\n# This zic input file is in the public domain.\n")); err != nil {
+		t.Fatalf("error parsing: %s", err)
+	}
+	if v := s.Version(); v != "2019c" {
+		t.Fatalf("got version %q, want 2019c", v)
+	}
+	if v := testSourceFor(t, testSource).Version(); v != "" {
+		t.Fatalf("got version %q, want none", v)
+	}
+}